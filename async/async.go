@@ -3,6 +3,7 @@ package async
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -12,6 +13,15 @@ import (
 // Ants 对应的结构体
 var Async = &asyncStruct{}
 
+// ErrTimeout 执行超时
+var ErrTimeout = errors.New("执行超时")
+
+// ErrCanceled 调用方取消执行
+var ErrCanceled = errors.New("执行已取消")
+
+// ErrPanic 执行过程中发生 panic
+var ErrPanic = errors.New("执行异常")
+
 type asyncStruct struct{}
 
 type ResultInfo struct {
@@ -25,6 +35,23 @@ func (p *asyncStruct) AsyncRun(
 	logName string,
 	concurrent int,
 	timeout *int) (interface{}, error) {
+	return p.AsyncRunContext(context.Background(), func(ctx context.Context) (interface{}, error) {
+		return execFunc()
+	}, nil, logName, concurrent, timeout)
+}
+
+// AsyncRunContext 在协程池中执行execFunc，同时监听调用方ctx与超时
+// 超时或取消时调用interrupt通知执行方中止（如 goja.Runtime.Interrupt），使协程池的worker尽快释放
+func (p *asyncStruct) AsyncRunContext(
+	ctx context.Context,
+	execFunc func(ctx context.Context) (interface{}, error),
+	interrupt func(err error),
+	logName string,
+	concurrent int,
+	timeout *int) (interface{}, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	resultChan := make(chan interface{}, 1)
 	errChan := make(chan error, 1)
 
@@ -35,27 +62,29 @@ func (p *asyncStruct) AsyncRun(
 	}
 
 	// 创建定时context
-	timeoutCtx, cancel := context.WithTimeout(context.Background(), timerDuration)
+	timeoutCtx, cancel := context.WithTimeout(ctx, timerDuration)
 	defer cancel()
 
 	// 异步调用 plugin.Execute，并获取结果
 	done := make(chan bool) // 添加一个信号通道用来表示协程是否完成
 
 	// 协程库调用
-	ants.Ants.Submit(logName, func() {
+	err := ants.Ants.Submit(logName, func() {
 		defer close(done) // 在结束时关闭done通道
-		r, e := execFunc()
+		// panic 时同样返回结果，避免等待方一直阻塞
+		defer func() {
+			if r := recover(); r != nil {
+				resultChan <- nil
+				errChan <- fmt.Errorf("%w: %v", ErrPanic, r)
+			}
+		}()
+		r, e := execFunc(timeoutCtx)
 		resultChan <- r
 		errChan <- e
 	}, concurrent)
-
-	// 普通调用
-	// go func() {
-	// 	defer close(done) // 在结束时关闭done通道
-	// 	r, e := execFunc()
-	// 	resultChan <- r
-	// 	errChan <- e
-	// }()
+	if err != nil {
+		return nil, err
+	}
 
 	// 等待结果或错误
 	var finalResult interface{}
@@ -63,13 +92,18 @@ func (p *asyncStruct) AsyncRun(
 	select {
 	// 等待协程执行完毕
 	case <-done:
-		// defer close(resultChan)
-		// defer close(errChan)
 		finalResult = <-resultChan
 		finalErr = <-errChan
 	case <-timeoutCtx.Done():
-		// 如果超时，执行相应的处理逻辑
-		finalErr = errors.New("执行超时，Timeout: " + timerDuration.String())
+		// 超时或调用方取消，执行相应的处理逻辑
+		if ctx.Err() != nil && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			finalErr = fmt.Errorf("%w: %v", ErrCanceled, context.Cause(ctx))
+		} else {
+			finalErr = fmt.Errorf("%w，Timeout: %s", ErrTimeout, timerDuration.String())
+		}
+		if interrupt != nil {
+			interrupt(finalErr)
+		}
 	}
 
 	if finalErr != nil {
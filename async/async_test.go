@@ -0,0 +1,63 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAsyncRunContextPanic(t *testing.T) {
+	timeout := 5
+	done := make(chan struct{})
+	var err error
+	go func() {
+		defer close(done)
+		_, err = Async.AsyncRunContext(context.Background(), func(ctx context.Context) (interface{}, error) {
+			panic("boom")
+		}, nil, "test", 1, &timeout)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("AsyncRunContext blocked after panic")
+	}
+	if !errors.Is(err, ErrPanic) {
+		t.Fatalf("err = %v, want ErrPanic", err)
+	}
+}
+
+func TestAsyncRunContext(t *testing.T) {
+	timeout := 1
+	tests := []struct {
+		name    string
+		exec    func(ctx context.Context) (interface{}, error)
+		want    interface{}
+		wantErr error
+	}{
+		{
+			name: "result",
+			exec: func(ctx context.Context) (interface{}, error) { return 1, nil },
+			want: 1,
+		},
+		{
+			name: "timeout",
+			exec: func(ctx context.Context) (interface{}, error) {
+				<-ctx.Done()
+				return nil, nil
+			},
+			wantErr: ErrTimeout,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Async.AsyncRunContext(context.Background(), tt.exec, nil, "test", 1, &timeout)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if result != tt.want {
+				t.Fatalf("result = %v, want %v", result, tt.want)
+			}
+		})
+	}
+}
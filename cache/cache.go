@@ -20,11 +20,14 @@ var DefaultExpiration = 600
 type cacheStruct struct{}
 
 // 获取缓存数据
+// 对象与数组经过 JSON 序列化复制后返回，数字统一为 float64，结构体转换为 map，与缓存中的数据互不影响
 func (p *cacheStruct) Get(key string) (interface{}, bool) {
 	key = strings.TrimSpace(key)
 	result, ok := cacheStorage.Get(key)
 	if ok {
-		result = json.JSON.Clone(result)
+		if underscore.Underscore.IsObject(result) {
+			result, _ = json.JSON.Parse(json.JSON.Stringify(result))
+		}
 		return result, true
 	} else {
 		return nil, false
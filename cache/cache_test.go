@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetReturnsJSONCopy(t *testing.T) {
+	type item struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+	tests := []struct {
+		name string
+		data interface{}
+		want interface{}
+	}{
+		{"scalar", 1, 1},
+		{"string", "a", "a"},
+		{"map", map[string]interface{}{"n": 1, "list": []interface{}{int64(2)}},
+			map[string]interface{}{"n": 1.0, "list": []interface{}{2.0}}},
+		{"struct", item{Name: "a", Count: 2}, map[string]interface{}{"name": "a", "count": 2.0}},
+		{"struct slice", []item{{Name: "a", Count: 2}}, []interface{}{map[string]interface{}{"name": "a", "count": 2.0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := "test:" + tt.name
+			Cache.Set(key, tt.data, nil)
+			defer Cache.Delete(key)
+			got, ok := Cache.Get(key)
+			if !ok || !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Get = %#v, %v, want %#v", got, ok, tt.want)
+			}
+		})
+	}
+
+	data := map[string]interface{}{"n": 1.0}
+	Cache.Set("test:copy", data, nil)
+	defer Cache.Delete("test:copy")
+	got, _ := Cache.Get("test:copy")
+	got.(map[string]interface{})["n"] = 2.0
+	if again, _ := Cache.Get("test:copy"); again.(map[string]interface{})["n"] != 1.0 {
+		t.Fatalf("cached value modified through Get: %v", again)
+	}
+}
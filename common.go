@@ -12,7 +12,14 @@ import (
 
 var Logger *logger.LoggerEntry
 
-func ShowSysStat(prefix, reqId string, maxMem int, logger *logger.LoggerEntry) {
+// ErrnoOutOfMemory 堆内存超出上限
+const ErrnoOutOfMemory = 1001
+
+// ErrOutOfMemory 堆内存超出上限，可用 errors.Is(err, utils.ErrOutOfMemory) 判断
+var ErrOutOfMemory = NewError(ErrnoOutOfMemory, "系统运行异常，内存溢出")
+
+// ShowSysStat 输出内存使用情况，堆内存超过 maxMem 时记录错误并返回 ErrOutOfMemory，由调用方决定如何处理
+func ShowSysStat(prefix, reqId string, maxMem int, logger *logger.LoggerEntry) error {
 	// runtime.GC()
 	numGoroutines := runtime.NumGoroutine()
 
@@ -32,9 +39,10 @@ func ShowSysStat(prefix, reqId string, maxMem int, logger *logger.LoggerEntry) {
 	logger.Warn("  栈的总数量: ", stackInUse)
 
 	if memStats.HeapAlloc > uint64(maxMem) {
-		logger.Fatal("系统运行异常，内存溢出")
-		os.Exit(2)
+		logger.Error("系统运行异常，内存溢出")
+		return &CustomError{Errno: ErrnoOutOfMemory, Msg: ErrOutOfMemory.Msg, Data: memStats.HeapAlloc}
 	}
+	return nil
 }
 
 func formatBytes(bytes uint64) string {
@@ -60,7 +68,10 @@ func Monitor(logger *logger.LoggerEntry) {
 		// 定时等待直到next时间
 		<-time.After(time.Until(nextRunTime))
 
-		ShowSysStat("Begin:", "", int(10240000000), logger)
+		// 超出内存上限时只记录，监控继续运行
+		if err := ShowSysStat("Begin:", "", int(10240000000), logger); err != nil {
+			logger.Error("Monitor: ", err.Error())
+		}
 		// 获取 Goroutine 的信息
 		pprof.Lookup("goroutine").WriteTo(os.Stdout, 1)
 	}
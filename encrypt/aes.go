@@ -0,0 +1,113 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Encrypt/Decrypt 使用的密钥派生参数
+const (
+	saltSize         = 16
+	pbkdf2Iterations = 100000
+	aesKeySize       = 32 // AES-256
+)
+
+// PBKDF2 按 RFC 8018 由密码派生密钥，algorithm 可选 md5、sha1、sha256、sha512
+func PBKDF2(password, salt []byte, iterations, keyLen int, algorithm string) ([]byte, error) {
+	newHash, err := hashFunc(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if iterations <= 0 || keyLen <= 0 {
+		return nil, errors.New("pbkdf2 iterations and key length must be positive")
+	}
+	return pbkdf2.Key(password, salt, iterations, keyLen, newHash), nil
+}
+
+// PBKDF2Blocks 返回派生密钥需要计算的 HMAC 次数：iterations × ceil(keyLen / 摘要长度)，用于限制派生的开销
+func PBKDF2Blocks(iterations, keyLen int, algorithm string) (int64, error) {
+	newHash, err := hashFunc(algorithm)
+	if err != nil {
+		return 0, err
+	}
+	if iterations <= 0 || keyLen <= 0 {
+		return 0, errors.New("pbkdf2 iterations and key length must be positive")
+	}
+	hashLen := newHash().Size()
+	return int64(iterations) * int64((keyLen+hashLen-1)/hashLen), nil
+}
+
+// AESGCMEncrypt 使用 AES-GCM 加密，key 长度为 16/24/32 字节，返回 nonce + 密文
+func AESGCMEncrypt(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := RandomBytes(gcm.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// AESGCMDecrypt 解密 AESGCMEncrypt 的结果
+func AESGCMDecrypt(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("aes-gcm ciphertext is too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// Encrypt 使用密码加密：PBKDF2-SHA256 派生 AES-256 密钥，结果为 base64(salt + nonce + 密文)
+func Encrypt(plaintext, password string) (string, error) {
+	salt, err := RandomBytes(saltSize)
+	if err != nil {
+		return "", err
+	}
+	key, err := PBKDF2([]byte(password), salt, pbkdf2Iterations, aesKeySize, "sha256")
+	if err != nil {
+		return "", err
+	}
+	sealed, err := AESGCMEncrypt(key, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(append(salt, sealed...)), nil
+}
+
+// Decrypt 解密 Encrypt 的结果，密码错误或数据被篡改时返回错误
+func Decrypt(data, password string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", err
+	}
+	if len(raw) < saltSize {
+		return "", errors.New("aes-gcm ciphertext is too short")
+	}
+	key, err := PBKDF2([]byte(password), raw[:saltSize], pbkdf2Iterations, aesKeySize, "sha256")
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := AESGCMDecrypt(key, raw[saltSize:])
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,98 @@
+package encrypt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// RFC 6070（PBKDF2-HMAC-SHA1）与 RFC 7914 第11节（PBKDF2-HMAC-SHA256）的测试向量
+func TestPBKDF2KnownAnswers(t *testing.T) {
+	tests := []struct {
+		password, salt string
+		iterations     int
+		keyLen         int
+		algorithm      string
+		want           string
+	}{
+		{"password", "salt", 1, 20, "sha1", "0c60c80f961f0e71f3a9b524af6012062fe037a6"},
+		{"password", "salt", 2, 20, "sha1", "ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957"},
+		{"password", "salt", 4096, 20, "sha1", "4b007901b765489abead49d926f721d065a429c1"},
+		{"passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096, 25, "sha1",
+			"3d2eec4fe41c849b80c8d83662c0e44a8b291a964cf2f07038"},
+		{"pass\x00word", "sa\x00lt", 4096, 16, "sha1", "56fa6aa75548099dcc37d7f03425e0c3"},
+		{"passwd", "salt", 1, 64, "sha256", "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc" +
+			"49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{"Password", "NaCl", 80000, 64, "sha256", "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56" +
+			"a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"},
+	}
+	for _, tt := range tests {
+		key, err := PBKDF2([]byte(tt.password), []byte(tt.salt), tt.iterations, tt.keyLen, tt.algorithm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(key); got != tt.want {
+			t.Fatalf("PBKDF2(%q, %q, %d) = %s, want %s", tt.password, tt.salt, tt.iterations, got, tt.want)
+		}
+	}
+	if _, err := PBKDF2([]byte("p"), []byte("s"), 0, 16, "sha256"); err == nil {
+		t.Fatal("zero iterations returned no error")
+	}
+}
+
+func TestPBKDF2Blocks(t *testing.T) {
+	tests := []struct {
+		iterations, keyLen int
+		algorithm          string
+		want               int64
+	}{
+		{1000, 32, "sha256", 1000},
+		{1000, 33, "sha256", 2000},
+		{1000, 64, "sha1", 4000},
+	}
+	for _, tt := range tests {
+		got, err := PBKDF2Blocks(tt.iterations, tt.keyLen, tt.algorithm)
+		if err != nil || got != tt.want {
+			t.Fatalf("PBKDF2Blocks(%d, %d, %s) = %d, %v, want %d", tt.iterations, tt.keyLen, tt.algorithm, got, err, tt.want)
+		}
+	}
+}
+
+func TestAESGCM(t *testing.T) {
+	// NIST GCM 规范测试用例2：全零密钥、nonce 与明文
+	zero := make([]byte, 16)
+	data, _ := hex.DecodeString("000000000000000000000000" + "0388dace60b6a392f328c2b971b2fe78" + "ab6e47d42cec13bdf53a67b21257bddf")
+	plaintext, err := AESGCMDecrypt(zero, data)
+	if err != nil || !bytes.Equal(plaintext, zero) {
+		t.Fatalf("AESGCMDecrypt known answer = %x, %v", plaintext, err)
+	}
+
+	key := bytes.Repeat([]byte{7}, 32)
+	sealed, err := AESGCMEncrypt(key, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext, err = AESGCMDecrypt(key, sealed); err != nil || string(plaintext) != "hello" {
+		t.Fatalf("AESGCMDecrypt = %q, %v, want hello", plaintext, err)
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err = AESGCMDecrypt(key, sealed); err == nil {
+		t.Fatal("tampered ciphertext decrypted without error")
+	}
+	if _, err = AESGCMEncrypt(key[:10], []byte("hello")); err == nil {
+		t.Fatal("invalid key length returned no error")
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	data, err := Encrypt("秘密 message", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Decrypt(data, "password"); err != nil || got != "秘密 message" {
+		t.Fatalf("Decrypt = %q, %v", got, err)
+	}
+	if _, err := Decrypt(data, "wrong"); err == nil {
+		t.Fatal("wrong password decrypted without error")
+	}
+}
@@ -0,0 +1,79 @@
+package encrypt
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+func Base64Encode(data string) string {
+	return base64.StdEncoding.EncodeToString([]byte(data))
+}
+
+func Base64Decode(data string) (string, error) {
+	result, err := base64.StdEncoding.DecodeString(data)
+	return string(result), err
+}
+
+// Base64URLEncode URL 安全的 base64，不带填充
+func Base64URLEncode(data string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(data))
+}
+
+// Base64URLDecode 解码 URL 安全的 base64，兼容带填充的输入
+func Base64URLDecode(data string) (string, error) {
+	result, err := base64.RawURLEncoding.DecodeString(trimPadding(data))
+	return string(result), err
+}
+
+func HexEncode(data string) string {
+	return hex.EncodeToString([]byte(data))
+}
+
+func HexDecode(data string) (string, error) {
+	result, err := hex.DecodeString(data)
+	return string(result), err
+}
+
+// RandomBytes 单次最多生成的字节数
+const MaxRandomBytes = 1 << 20
+
+// RandomBytes 生成加密安全的随机字节，n 需在 0 到 MaxRandomBytes 之间
+func RandomBytes(n int) ([]byte, error) {
+	if n < 0 || n > MaxRandomBytes {
+		return nil, errors.New("random bytes length out of range")
+	}
+	result := make([]byte, n)
+	if _, err := rand.Read(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UUIDv4 随机 UUID
+func UUIDv4() (string, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// UUIDv7 按时间排序的 UUID，适合作为数据库主键
+func UUIDv7() (string, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+func trimPadding(data string) string {
+	for len(data) > 0 && data[len(data)-1] == '=' {
+		data = data[:len(data)-1]
+	}
+	return data
+}
@@ -0,0 +1,58 @@
+package encrypt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRandomBytesLength(t *testing.T) {
+	tests := []struct {
+		n       int
+		wantErr bool
+	}{
+		{n: 0},
+		{n: 16},
+		{n: MaxRandomBytes},
+		{n: -1, wantErr: true},
+		{n: MaxRandomBytes + 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		result, err := RandomBytes(tt.n)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("RandomBytes(%d) error = %v, wantErr %v", tt.n, err, tt.wantErr)
+		}
+		if err == nil && len(result) != tt.n {
+			t.Fatalf("RandomBytes(%d) returned %d bytes", tt.n, len(result))
+		}
+	}
+}
+
+func TestUUID(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      func() (string, error)
+		version byte
+	}{
+		{"v4", UUIDv4, '4'},
+		{"v7", UUIDv7, '7'},
+	}
+	for _, tt := range tests {
+		id, err := tt.fn()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// xxxxxxxx-xxxx-Vxxx-Nxxx-xxxxxxxxxxxx，N 为 RFC 4122 变体 8、9、a、b
+		if len(id) != 36 || id[8] != '-' || id[13] != '-' || id[18] != '-' || id[23] != '-' {
+			t.Fatalf("%s UUID %s has invalid format", tt.name, id)
+		}
+		if id[14] != tt.version {
+			t.Fatalf("%s UUID %s has version %c", tt.name, id, id[14])
+		}
+		if !strings.ContainsRune("89ab", rune(id[19])) {
+			t.Fatalf("%s UUID %s has variant %c", tt.name, id, id[19])
+		}
+		if _, err = HexDecode(strings.ReplaceAll(id, "-", "")); err != nil {
+			t.Fatalf("%s UUID %s is not hex: %v", tt.name, id, err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package encrypt
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"strings"
+)
+
+func SHA1(data string) string {
+	hash := sha1.Sum([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
+
+func SHA256(data string) string {
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
+
+func SHA512(data string) string {
+	hash := sha512.Sum512([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
+
+// HMAC 计算 HMAC，algorithm 可选 md5、sha1、sha256、sha512，结果为十六进制字符串
+func HMAC(algorithm, key, data string) (string, error) {
+	newHash, err := hashFunc(algorithm)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(newHash, []byte(key))
+	mac.Write([]byte(data))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// 根据名称获取哈希算法，名称不区分大小写，可带连字符，如 "SHA-256"
+func hashFunc(algorithm string) (func() hash.Hash, error) {
+	switch strings.ReplaceAll(strings.ToLower(algorithm), "-", "") {
+	case "md5":
+		return md5.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "sha256":
+		return sha256.New, nil
+	case "sha512":
+		return sha512.New, nil
+	}
+	return nil, errors.New("unsupported hash algorithm: " + algorithm)
+}
@@ -0,0 +1,45 @@
+package encrypt
+
+import "testing"
+
+func TestHashKnownAnswers(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(string) string
+		want string
+	}{
+		{"md5", MD5, "900150983cd24fb0d6963f7d28e17f72"},
+		{"sha1", SHA1, "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{"sha256", SHA256, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"sha512", SHA512, "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a" +
+			"2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f"},
+	}
+	for _, tt := range tests {
+		if got := tt.fn("abc"); got != tt.want {
+			t.Fatalf("%s(abc) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// RFC 2202 / RFC 4231 test case 2
+func TestHMACKnownAnswers(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		want      string
+	}{
+		{"md5", "750c783e6ab0b503eaa86e310a5db738"},
+		{"sha1", "effcdf6ae5eb2fa2d27416d5f184df9c259a7c79"},
+		{"SHA-256", "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"},
+		{"sha512", "164b7a7bfcf819e2e395fbe73b56e0a387bd64222e831fd610270cd7ea250554" +
+			"9758bf75c05a994a6d034f65f8f0e6fdcaeab1a34d4a6b4b636e070a38bce737"},
+	}
+	for _, tt := range tests {
+		got, err := HMAC(tt.algorithm, "Jefe", "what do ya want for nothing?")
+		if err != nil || got != tt.want {
+			t.Fatalf("HMAC(%s) = %s, %v, want %s", tt.algorithm, got, err, tt.want)
+		}
+	}
+	if _, err := HMAC("sha3", "k", "d"); err == nil {
+		t.Fatal("unsupported algorithm returned no error")
+	}
+}
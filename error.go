@@ -5,9 +5,10 @@ import (
 )
 
 type UtilsTool struct {
-	Name   string
-	Debug  []string
-	Logger *logger.LoggerEntry
+	Name    string
+	Debug   []string
+	Logger  *logger.LoggerEntry
+	Modules []string // 允许 require 的模块白名单，如 "JSON"、"dayjs@2.0.0"，为空时不限制
 }
 
 type CustomError struct {
@@ -27,3 +28,17 @@ func NewError(errno int, msg string) *CustomError {
 func (e *CustomError) Error() string {
 	return e.Msg
 }
+
+// Is 按错误码比较，支持 errors.Is(err, utils.NewError(errno, ""))
+func (e *CustomError) Is(target error) bool {
+	t, ok := target.(*CustomError)
+	return ok && t.Errno == e.Errno
+}
+
+// Unwrap Data 为 error 时返回 Data，支持 errors.As 获取具体的错误类型
+func (e *CustomError) Unwrap() error {
+	if err, ok := e.Data.(error); ok {
+		return err
+	}
+	return nil
+}
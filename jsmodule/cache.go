@@ -1,6 +1,9 @@
 package jsmodule
 
 import (
+	"context"
+
+	"github.com/dop251/goja"
 	"github.com/skyfox2000/nect-utils/cache"
 )
 
@@ -33,6 +36,30 @@ var cacheModule = map[string]interface{}{
 	},
 }
 
+// 异步版本在协程池中访问缓存，脚本中使用 await Cache.getAsync(key)
+func newCacheModule(mc *ModuleContext) interface{} {
+	module := Static(cacheModule)(mc).(map[string]interface{})
+	module["getAsync"] = func(key string) goja.Value {
+		return mc.Promise(func(ctx context.Context) (interface{}, error) {
+			result, _ := cache.Cache.Get(key)
+			return result, nil
+		})
+	}
+	module["mgetAsync"] = func(keys interface{}) goja.Value {
+		return mc.Promise(func(ctx context.Context) (interface{}, error) {
+			return cache.Cache.MGet(keys), nil
+		})
+	}
+	module["setAsync"] = func(key string, data interface{}, exp *int) goja.Value {
+		return mc.Promise(func(ctx context.Context) (interface{}, error) {
+			cache.Cache.Set(key, data, exp)
+			return nil, nil
+		})
+	}
+	return module
+}
+
 func init() {
 	JSModules["Cache"] = cacheModule
+	DefaultRegistry.Register("Cache", newCacheModule)
 }
@@ -3,5 +3,25 @@ package jsmodule
 import "github.com/skyfox2000/nect-utils/logger"
 
 // js模块map
+// Deprecated: 新模块请使用 DefaultRegistry.Register 注册，直接加入此map的模块仍可被 require
 var JSModules = map[string]map[string]interface{}{}
 var Logger *logger.LoggerEntry
+
+// 注册内置模块，同时保留在 JSModules 中兼容旧代码
+func registerStatic(name string, module map[string]interface{}) {
+	JSModules[name] = module
+	DefaultRegistry.Register(name, Static(module))
+}
+
+// Resolve 在 DefaultRegistry 中查找模块，未找到时兼容直接加入 JSModules 的模块
+func Resolve(spec string, allow []string) (string, ModuleFactory, error) {
+	key, factory, err := DefaultRegistry.Resolve(spec, allow)
+	if err == nil {
+		return key, factory, nil
+	}
+	name, version := splitModuleName(spec)
+	if module, ok := JSModules[name]; ok && version == "" && isAllowed(name, "", allow) {
+		return name, Static(module), nil
+	}
+	return "", nil, err
+}
@@ -1,15 +1,90 @@
 package jsmodule
 
 import (
+	"encoding/hex"
+	"errors"
+
 	"github.com/skyfox2000/nect-utils/encrypt"
 )
 
+// 脚本可以请求的上限，避免单次调用长时间占用执行协程或分配过多内存
+const (
+	maxScriptRandomBytes  = 64 * 1024
+	maxScriptPBKDF2Blocks = 1000000 // iterations × ceil(keyLen / 摘要长度)
+)
+
+// 返回 error 的函数在脚本中失败时抛出异常
 var cryptoModule = map[string]interface{}{
 	"md5": func(data string) string {
 		return encrypt.MD5(data)
 	},
+	"sha1": func(data string) string {
+		return encrypt.SHA1(data)
+	},
+	"sha256": func(data string) string {
+		return encrypt.SHA256(data)
+	},
+	"sha512": func(data string) string {
+		return encrypt.SHA512(data)
+	},
+	"hmac": func(algorithm, key, data string) (string, error) {
+		return encrypt.HMAC(algorithm, key, data)
+	},
+	// 派生密钥，结果为十六进制字符串，algorithm 默认 sha256
+	"pbkdf2": func(password, salt string, iterations, keyLen int, algorithm string) (string, error) {
+		if algorithm == "" {
+			algorithm = "sha256"
+		}
+		blocks, err := encrypt.PBKDF2Blocks(iterations, keyLen, algorithm)
+		if err != nil {
+			return "", err
+		}
+		if blocks > maxScriptPBKDF2Blocks {
+			return "", errors.New("pbkdf2 iterations × blocks exceeds the limit")
+		}
+		key, err := encrypt.PBKDF2([]byte(password), []byte(salt), iterations, keyLen, algorithm)
+		return hex.EncodeToString(key), err
+	},
+	"encrypt": func(plaintext, password string) (string, error) {
+		return encrypt.Encrypt(plaintext, password)
+	},
+	"decrypt": func(data, password string) (string, error) {
+		return encrypt.Decrypt(data, password)
+	},
+	"base64Encode": func(data string) string {
+		return encrypt.Base64Encode(data)
+	},
+	"base64Decode": func(data string) (string, error) {
+		return encrypt.Base64Decode(data)
+	},
+	"base64UrlEncode": func(data string) string {
+		return encrypt.Base64URLEncode(data)
+	},
+	"base64UrlDecode": func(data string) (string, error) {
+		return encrypt.Base64URLDecode(data)
+	},
+	"hexEncode": func(data string) string {
+		return encrypt.HexEncode(data)
+	},
+	"hexDecode": func(data string) (string, error) {
+		return encrypt.HexDecode(data)
+	},
+	// 随机字节，结果为十六进制字符串
+	"randomBytes": func(n int) (string, error) {
+		if n < 0 || n > maxScriptRandomBytes {
+			return "", errors.New("random bytes length out of range")
+		}
+		result, err := encrypt.RandomBytes(n)
+		return hex.EncodeToString(result), err
+	},
+	"uuid": func() (string, error) {
+		return encrypt.UUIDv4()
+	},
+	"uuidV7": func() (string, error) {
+		return encrypt.UUIDv7()
+	},
 }
 
 func init() {
-	JSModules["crypto"] = cryptoModule
-}
\ No newline at end of file
+	registerStatic("crypto", cryptoModule)
+}
@@ -0,0 +1,24 @@
+package jsmodule
+
+import "testing"
+
+func TestScriptPBKDF2Limit(t *testing.T) {
+	pbkdf2 := cryptoModule["pbkdf2"].(func(password, salt string, iterations, keyLen int, algorithm string) (string, error))
+	tests := []struct {
+		iterations, keyLen int
+		algorithm          string
+		wantErr            bool
+	}{
+		{1, 32, "", false},
+		{maxScriptPBKDF2Blocks, 33, "sha256", true}, // 两个块
+		{maxScriptPBKDF2Blocks/2 + 1, 64, "sha256", true},
+		{1, 1 << 30, "sha1", true},
+		{0, 32, "sha256", true},
+	}
+	for _, tt := range tests {
+		_, err := pbkdf2("p", "s", tt.iterations, tt.keyLen, tt.algorithm)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("pbkdf2(%d, %d) error = %v, wantErr %v", tt.iterations, tt.keyLen, err, tt.wantErr)
+		}
+	}
+}
@@ -2,21 +2,51 @@ package jsmodule
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// Dayjs dayjs 模块的配置
+var Dayjs = &dayjsStruct{Locale: "en"}
+
+type dayjsStruct struct {
+	Locale   string         // 默认语言，可选 en、zh-CN，影响月份/星期名称与一周的第一天
+	Location *time.Location // 不带时区的字符串与时间戳使用的时区，默认 time.Local
+}
+
+// 没有指定格式时的输出格式，与 dayjs 一致
+const defaultDayjsFormat = "YYYY-MM-DDTHH:mm:ssZ"
+
+// tz/utc 返回的字符串格式，带时区偏移，可再次传入其他函数
+const isoLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// 时间单位
+const (
+	unitYear        = "year"
+	unitQuarter     = "quarter"
+	unitMonth       = "month"
+	unitWeek        = "week"
+	unitDay         = "day"
+	unitHour        = "hour"
+	unitMinute      = "minute"
+	unitSecond      = "second"
+	unitMillisecond = "millisecond"
+)
+
 // dayjs模块
+// 日期参数可以是字符串、毫秒时间戳或 Date；add/subtract/startOf/endOf 返回与输入相同类型的结果：
+// 字符串按输入的格式输出，时间戳与 Date 返回毫秒时间戳
 var dayjsModule = map[string]interface{}{
-	"format": func(date interface{}, format string) (string, error) {
-		goTime, _, err := convertToGoTime(date)
+	"format": func(date interface{}, format string, locale string) (string, error) {
+		goTime, _, err := convertToGoTime(date, nil)
 		if err != nil {
 			return "", err
 		}
-
-		targetFormat := customFormat(format)
-		return goTime.Format(targetFormat), nil
+		return formatTime(goTime, format, locale), nil
 	},
 	"date": func() string {
 		return time.Now().Format("2006-01-02")
@@ -33,87 +63,340 @@ var dayjsModule = map[string]interface{}{
 	"unixnano": func() int64 {
 		return time.Now().UnixNano()
 	},
-	"add": func(datetime interface{}, duration int, period string) (string, error) {
+	"add": func(datetime interface{}, duration int, period string) (interface{}, error) {
 		return calculateTime(datetime, duration, period, 1)
 	},
-	"subtract": func(datetime interface{}, duration int, period string) (string, error) {
+	"subtract": func(datetime interface{}, duration int, period string) (interface{}, error) {
 		return calculateTime(datetime, duration, period, -1)
 	},
-	"diff": func(datetime1 interface{}, datetime2 interface{}, period string) (int, error) {
-		goTime1, _, err := convertToGoTime(datetime1)
+	// datetime1 - datetime2，结果默认向零取整，float 为 true 时返回小数
+	"diff": func(datetime1 interface{}, datetime2 interface{}, period string, float bool) (interface{}, error) {
+		goTime1, _, err := convertToGoTime(datetime1, nil)
 		if err != nil {
 			return 0, err
 		}
-
-		goTime2, _, err := convertToGoTime(datetime2)
+		goTime2, _, err := convertToGoTime(datetime2, nil)
 		if err != nil {
 			return 0, err
 		}
-
-		switch period {
-		case "seconds":
-			return int(goTime1.Sub(goTime2).Seconds()), nil
-		case "minutes":
-			return int(goTime1.Sub(goTime2).Minutes()), nil
-		case "hours":
-			return int(goTime1.Sub(goTime2).Hours()), nil
-		case "days":
-			return int(goTime1.Sub(goTime2).Hours() / 24), nil
+		result, err := diffTime(goTime1, goTime2, period)
+		if err != nil {
+			return 0, err
+		}
+		if float {
+			return result, nil
+		}
+		return int64(math.Trunc(result)), nil
+	},
+	"startOf": func(datetime interface{}, unit string) (interface{}, error) {
+		return boundaryTime(datetime, unit, false)
+	},
+	"endOf": func(datetime interface{}, unit string) (interface{}, error) {
+		return boundaryTime(datetime, unit, true)
+	},
+	"isBefore": func(datetime1 interface{}, datetime2 interface{}, unit string) (bool, error) {
+		return compareTime(datetime1, datetime2, unit, "before")
+	},
+	"isAfter": func(datetime1 interface{}, datetime2 interface{}, unit string) (bool, error) {
+		return compareTime(datetime1, datetime2, unit, "after")
+	},
+	"isSame": func(datetime1 interface{}, datetime2 interface{}, unit string) (bool, error) {
+		return compareTime(datetime1, datetime2, unit, "same")
+	},
+	"isValid": func(datetime interface{}) bool {
+		_, _, err := convertToGoTime(datetime, nil)
+		return err == nil
+	},
+	"daysInMonth": func(datetime interface{}) (int, error) {
+		goTime, _, err := convertToGoTime(datetime, nil)
+		if err != nil {
+			return 0, err
+		}
+		return daysIn(goTime.Year(), goTime.Month()), nil
+	},
+	// 转换到 IANA 时区，如 "Asia/Shanghai"；不带时区的字符串视为该时区的时间
+	// 返回带时区偏移的 ISO 字符串，后续 format 按该时区输出
+	"tz": func(datetime interface{}, zone string) (string, error) {
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			return "", err
+		}
+		goTime, _, err := convertToGoTime(datetime, loc)
+		if err != nil {
+			return "", err
+		}
+		return goTime.In(loc).Format(isoLayout), nil
+	},
+	// 按指定格式解析，如 parse('31/01/2024', 'DD/MM/YYYY')，zone 为空时使用默认时区
+	// 返回带时区偏移的 ISO 字符串，可传入其他函数
+	"parse": func(value string, format string, zone string) (string, error) {
+		var loc *time.Location
+		if zone != "" {
+			var err error
+			if loc, err = time.LoadLocation(zone); err != nil {
+				return "", err
+			}
+		}
+		goTime, err := Dayjs.Parse(value, format, loc)
+		if err != nil {
+			return "", err
+		}
+		return goTime.Format(isoLayout), nil
+	},
+	// 转换为 UTC 时间，返回 ISO 字符串，后续 format 按 UTC 输出
+	"utc": func(datetime interface{}) (string, error) {
+		goTime, _, err := convertToGoTime(datetime, nil)
+		if err != nil {
+			return "", err
 		}
-		return 0, errors.New("unsupported period")
+		return goTime.UTC().Format(isoLayout), nil
 	},
 }
 
 func init() {
-	JSModules["dayjs"] = dayjsModule
+	registerStatic("dayjs", dayjsModule)
+}
+
+func calculateTime(datetime interface{}, duration int, period string, multiplier int) (interface{}, error) {
+	goTime, format, err := convertToGoTime(datetime, nil)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := addTime(goTime, multiplier*duration, period)
+	if err != nil {
+		return "", err
+	}
+	return outputTime(result, datetime, format), nil
+}
+
+func addTime(goTime time.Time, n int, period string) (time.Time, error) {
+	switch normalizeUnit(period) {
+	case unitMillisecond:
+		return goTime.Add(time.Duration(n) * time.Millisecond), nil
+	case unitSecond:
+		return goTime.Add(time.Duration(n) * time.Second), nil
+	case unitMinute:
+		return goTime.Add(time.Duration(n) * time.Minute), nil
+	case unitHour:
+		return goTime.Add(time.Duration(n) * time.Hour), nil
+	case unitDay:
+		return goTime.AddDate(0, 0, n), nil
+	case unitWeek:
+		return goTime.AddDate(0, 0, 7*n), nil
+	case unitMonth:
+		return addMonths(goTime, n), nil
+	case unitQuarter:
+		return addMonths(goTime, 3*n), nil
+	case unitYear:
+		return addMonths(goTime, 12*n), nil
+	}
+	return time.Time{}, errors.New("unsupported period")
+}
+
+// 加减月份，日期超出目标月份的天数时取月末，如 1月31日加1个月为2月28日
+func addMonths(goTime time.Time, n int) time.Time {
+	first := time.Date(goTime.Year(), goTime.Month(), 1, goTime.Hour(), goTime.Minute(), goTime.Second(), goTime.Nanosecond(), goTime.Location())
+	target := first.AddDate(0, n, 0)
+	day := goTime.Day()
+	if days := daysIn(target.Year(), target.Month()); day > days {
+		day = days
+	}
+	return target.AddDate(0, 0, day-1)
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// 计算 a - b，算法与 dayjs 一致：月、季度、年按月份差计算，天、周扣除时区偏移的变化
+func diffTime(a, b time.Time, period string) (float64, error) {
+	diff := float64(a.Sub(b).Milliseconds())
+	_, offsetA := a.Zone()
+	_, offsetB := b.Zone()
+	zoneDelta := float64(offsetB-offsetA) * 1000
+
+	switch normalizeUnit(period) {
+	case unitYear:
+		return monthDiff(a, b) / 12, nil
+	case unitQuarter:
+		return monthDiff(a, b) / 3, nil
+	case unitMonth:
+		return monthDiff(a, b), nil
+	case unitWeek:
+		return (diff - zoneDelta) / float64(7*24*time.Hour/time.Millisecond), nil
+	case unitDay:
+		return (diff - zoneDelta) / float64(24*time.Hour/time.Millisecond), nil
+	case unitHour:
+		return diff / float64(time.Hour/time.Millisecond), nil
+	case unitMinute:
+		return diff / float64(time.Minute/time.Millisecond), nil
+	case unitSecond:
+		return diff / 1000, nil
+	case unitMillisecond:
+		return diff, nil
+	}
+	return 0, errors.New("unsupported period")
+}
+
+// a - b 相差的月数，不足一个月的部分按所在月份的长度折算为小数
+func monthDiff(a, b time.Time) float64 {
+	if a.Day() < b.Day() {
+		return -monthDiff(b, a)
+	}
+	whole := (b.Year()-a.Year())*12 + int(b.Month()-a.Month())
+	anchor := addMonths(a, whole)
+	var anchor2 time.Time
+	var result float64
+	if b.Before(anchor) {
+		anchor2 = addMonths(a, whole-1)
+		result = float64(whole) + float64(b.Sub(anchor))/float64(anchor.Sub(anchor2))
+	} else {
+		anchor2 = addMonths(a, whole+1)
+		result = float64(whole) + float64(b.Sub(anchor))/float64(anchor2.Sub(anchor))
+	}
+	if result == 0 || math.IsNaN(result) {
+		return 0
+	}
+	return -result
 }
 
-func calculateTime(datetime interface{}, duration int, period string, multiplier int) (string, error) {
-	goTime, format, err := convertToGoTime(datetime)
+func boundaryTime(datetime interface{}, unit string, end bool) (interface{}, error) {
+	goTime, format, err := convertToGoTime(datetime, nil)
 	if err != nil {
 		return "", err
 	}
+	result, err := startOfTime(goTime, unit, end)
+	if err != nil {
+		return "", err
+	}
+	return outputTime(result, datetime, format), nil
+}
 
-	var result time.Time
-	switch period {
-	case "seconds":
-		result = goTime.Add(time.Duration(multiplier*duration) * time.Second)
-	case "minutes":
-		result = goTime.Add(time.Duration(multiplier*duration) * time.Minute)
-	case "hours":
-		result = goTime.Add(time.Duration(multiplier*duration) * time.Hour)
-	case "days":
-		result = goTime.AddDate(0, 0, multiplier*duration)
-	case "weeks":
-		result = goTime.AddDate(0, 0, multiplier*7*duration)
-	case "months":
-		result = goTime.AddDate(0, multiplier*duration, 0)
-	case "years":
-		result = goTime.AddDate(multiplier*duration, 0, 0)
+// 单位的开始时间，end 为 true 时返回单位的最后一毫秒
+func startOfTime(goTime time.Time, unit string, end bool) (time.Time, error) {
+	y, m, d := goTime.Date()
+	loc := goTime.Location()
+	normalized := normalizeUnit(unit)
+	var start time.Time
+	switch normalized {
+	case unitYear:
+		start = time.Date(y, time.January, 1, 0, 0, 0, 0, loc)
+	case unitQuarter:
+		start = time.Date(y, m-(m-1)%3, 1, 0, 0, 0, 0, loc)
+	case unitMonth:
+		start = time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	case unitWeek:
+		offset := (int(goTime.Weekday()) - getDayjsLocale(Dayjs.Locale).weekStart + 7) % 7
+		start = time.Date(y, m, d-offset, 0, 0, 0, 0, loc)
+	case unitDay:
+		start = time.Date(y, m, d, 0, 0, 0, 0, loc)
+	case unitHour:
+		start = time.Date(y, m, d, goTime.Hour(), 0, 0, 0, loc)
+	case unitMinute:
+		start = time.Date(y, m, d, goTime.Hour(), goTime.Minute(), 0, 0, loc)
+	case unitSecond:
+		start = time.Date(y, m, d, goTime.Hour(), goTime.Minute(), goTime.Second(), 0, loc)
+	case unitMillisecond:
+		start = goTime.Truncate(time.Millisecond)
 	default:
-		return "", errors.New("unsupported period")
+		return time.Time{}, errors.New("unsupported unit: " + unit)
 	}
+	if !end {
+		return start, nil
+	}
+	next, _ := addTime(start, 1, normalized)
+	return next.Add(-time.Millisecond), nil
+}
 
+// 比较 a 与 b，unit 为空时按毫秒比较，否则按 a 所在单位的范围比较
+func compareTime(datetime1, datetime2 interface{}, unit, op string) (bool, error) {
+	a, _, err := convertToGoTime(datetime1, nil)
+	if err != nil {
+		return false, err
+	}
+	b, _, err := convertToGoTime(datetime2, nil)
+	if err != nil {
+		return false, err
+	}
+	if unit == "" {
+		unit = unitMillisecond
+	}
+	start, err := startOfTime(a, unit, false)
+	if err != nil {
+		return false, err
+	}
+	end, _ := startOfTime(a, unit, true)
+	switch op {
+	case "before":
+		return end.Before(b), nil
+	case "after":
+		return b.Before(start), nil
+	}
+	return !b.Before(start) && !b.After(end), nil
+}
+
+// 单位名称与 dayjs 相同，支持单复数与缩写，M 为月、m 为分钟
+var unitAliases = map[string]string{
+	"y": unitYear, "year": unitYear, "years": unitYear,
+	"Q": unitQuarter, "quarter": unitQuarter, "quarters": unitQuarter,
+	"M": unitMonth, "month": unitMonth, "months": unitMonth,
+	"w": unitWeek, "week": unitWeek, "weeks": unitWeek,
+	"d": unitDay, "D": unitDay, "day": unitDay, "days": unitDay, "date": unitDay, "dates": unitDay,
+	"h": unitHour, "hour": unitHour, "hours": unitHour,
+	"m": unitMinute, "minute": unitMinute, "minutes": unitMinute,
+	"s": unitSecond, "second": unitSecond, "seconds": unitSecond,
+	"ms": unitMillisecond, "millisecond": unitMillisecond, "milliseconds": unitMillisecond,
+}
+
+func normalizeUnit(unit string) string {
+	if normalized, ok := unitAliases[unit]; ok {
+		return normalized
+	}
+	return unitAliases[strings.ToLower(unit)]
+}
+
+// 按输入的类型输出：字符串按原格式输出，时间戳与 Date 返回毫秒时间戳
+func outputTime(result time.Time, datetime interface{}, format string) interface{} {
 	switch datetime.(type) {
 	case string:
-		return result.Format(format), nil
-	default:
-		return "", errors.New("unsupported date format")
+		return result.Format(format)
 	}
+	return result.UnixMilli()
+}
+
+// 自动识别的字符串格式，带小数秒的格式在前，保证按原精度输出
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05.000",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05.000",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04:05.999-07:00",
+	isoLayout,
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02 15:04:05Z07:00",
+	"2006/01/02",
+	"2006/01/02 15:04:05",
 }
 
-// 将日期数据转换为Go时间格式
-func convertToGoTime(date interface{}) (time.Time, string, error) {
+// 将日期数据转换为Go时间格式，返回的格式为字符串输入所使用的 Go layout
+// 不带时区的字符串按 loc 解析，时间戳与 Date 转换到 loc；loc 为空时使用 Dayjs.Location
+func convertToGoTime(date interface{}, loc *time.Location) (time.Time, string, error) {
+	if loc == nil {
+		loc = Dayjs.location()
+	}
 	switch date := date.(type) {
 	case string:
 		// 尝试解析日期字符串
-		formats := []string{"2006-01-02", "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02T15:04:05.999-07:00"}
 		var goTime time.Time
 		var parseError error
 
 		var format string
-		for _, f := range formats {
-			goTime, parseError = time.Parse(f, date)
+		for _, f := range dateLayouts {
+			goTime, parseError = time.ParseInLocation(f, date, loc)
 			if parseError == nil {
 				format = f
 				break
@@ -125,37 +408,202 @@ func convertToGoTime(date interface{}) (time.Time, string, error) {
 		}
 
 		return goTime, format, nil
+	case time.Time:
+		return date.In(loc), "Unix", nil
+	case int64:
+		return time.UnixMilli(date).In(loc), "Unix", nil
+	case int:
+		return time.UnixMilli(int64(date)).In(loc), "Unix", nil
 	case float64:
 		// 尝试解析JavaScript时间戳
 		seconds := int64(date / 1000)
 		nanos := int64((date - float64(seconds*1000)) * 1e6)
-		return time.Unix(seconds, nanos), "Unix", nil
+		return time.Unix(seconds, nanos).In(loc), "Unix", nil
 	default:
 		return time.Time{}, "", errors.New("unsupported date format")
 	}
 }
 
-func customFormat(inputFormat string) string {
-	targetFormat := inputFormat
-	re := regexp.MustCompile(`(YYYY|MM|DD|HH|mm|ss)`)
-	matches := re.FindAllString(targetFormat, -1)
+// Parse 按 dayjs 的格式化标记解析字符串，如 "DD/MM/YYYY HH:mm"
+// 不带时区的字符串按 loc 解析，loc 为空时使用 Location；月份与星期名称只支持英文
+func (p *dayjsStruct) Parse(value, format string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = p.location()
+	}
+	switch format {
+	case "":
+		goTime, _, err := convertToGoTime(value, loc)
+		return goTime, err
+	case "X", "x":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, errors.New("failed to parse date: " + err.Error())
+		}
+		if format == "X" {
+			return time.Unix(n, 0).In(loc), nil
+		}
+		return time.UnixMilli(n).In(loc), nil
+	}
+
+	layout, err := parseLayout(format)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return layout.parse(value, loc)
+}
+
+func (p *dayjsStruct) location() *time.Location {
+	if p.Location != nil {
+		return p.Location
+	}
+	return time.Local
+}
+
+// 解析时格式化标记匹配的文本与对应的 Go layout
+var parseLayoutTokens = map[string][2]string{
+	"YY": {`\d{2}`, "06"}, "YYYY": {`\d{4}`, "2006"},
+	"M": {`\d{1,2}`, "1"}, "MM": {`\d{2}`, "01"}, "MMM": {`[A-Za-z]{3}`, "Jan"}, "MMMM": {`[A-Za-z]+`, "January"},
+	"D": {`\d{1,2}`, "2"}, "DD": {`\d{2}`, "02"},
+	"ddd": {`[A-Za-z]{3}`, "Mon"}, "dddd": {`[A-Za-z]+`, "Monday"},
+	"H": {`\d{1,2}`, "15"}, "HH": {`\d{2}`, "15"}, "h": {`\d{1,2}`, "3"}, "hh": {`\d{2}`, "03"},
+	"a": {`[a-z]{2}`, "pm"}, "A": {`[A-Z]{2}`, "PM"},
+	"m": {`\d{1,2}`, "4"}, "mm": {`\d{2}`, "04"}, "s": {`\d{1,2}`, "5"}, "ss": {`\d{2}`, "05"},
+	"SSS": {`\d{3}`, "000"}, "Z": {`Z|[+-]\d{2}:\d{2}`, "Z07:00"}, "ZZ": {`Z|[+-]\d{4}`, "Z0700"},
+}
 
-	for _, match := range matches {
-		switch match {
-		case "YYYY":
-			targetFormat = strings.Replace(targetFormat, "YYYY", "2006", 1)
-		case "MM":
-			targetFormat = strings.Replace(targetFormat, "MM", "01", 1)
-		case "DD":
-			targetFormat = strings.Replace(targetFormat, "DD", "02", 1)
-		case "HH":
-			targetFormat = strings.Replace(targetFormat, "HH", "15", 1)
-		case "mm":
-			targetFormat = strings.Replace(targetFormat, "mm", "04", 1)
-		case "ss":
-			targetFormat = strings.Replace(targetFormat, "ss", "05", 1)
+// 按 dayjs 格式解析的规则
+// Go layout 无法转义字面文本，因此先用正则按格式切分字符串，字面文本与 [] 中的内容只按原文匹配，
+// 再将各标记匹配到的文本以 "|" 连接，按同样连接的 Go layout 解析
+type dayjsLayout struct {
+	pattern *regexp.Regexp
+	layout  string
+}
+
+// 将 dayjs 的格式转换为解析规则
+func parseLayout(format string) (*dayjsLayout, error) {
+	var pattern strings.Builder
+	var layouts []string
+	pattern.WriteString("^")
+	last := 0
+	for _, loc := range formatTokenPattern.FindAllStringIndex(format, -1) {
+		pattern.WriteString(regexp.QuoteMeta(format[last:loc[0]]))
+		token := format[loc[0]:loc[1]]
+		last = loc[1]
+		if strings.HasPrefix(token, "[") {
+			pattern.WriteString(regexp.QuoteMeta(token[1 : len(token)-1]))
+			continue
 		}
+		rule, ok := parseLayoutTokens[token]
+		if !ok {
+			return nil, errors.New("unsupported parse token: " + token)
+		}
+		pattern.WriteString("(" + rule[0] + ")")
+		layouts = append(layouts, rule[1])
 	}
+	pattern.WriteString(regexp.QuoteMeta(format[last:]) + "$")
+	reg, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, err
+	}
+	return &dayjsLayout{pattern: reg, layout: strings.Join(layouts, "|")}, nil
+}
+
+func (l *dayjsLayout) parse(value string, loc *time.Location) (time.Time, error) {
+	match := l.pattern.FindStringSubmatch(value)
+	if match == nil {
+		return time.Time{}, errors.New("failed to parse date: " + value + " does not match the format")
+	}
+	goTime, err := time.ParseInLocation(l.layout, strings.Join(match[1:], "|"), loc)
+	if err != nil {
+		return time.Time{}, errors.New("failed to parse date: " + err.Error())
+	}
+	return goTime, nil
+}
+
+// dayjs 的格式化标记，[] 中的内容原样输出
+var formatTokenPattern = regexp.MustCompile(`\[([^\]]+)]|Y{1,4}|M{1,4}|Do|D{1,2}|d{1,4}|H{1,2}|h{1,2}|a|A|m{1,2}|s{1,2}|Z{1,2}|SSS|Q|X|x`)
 
-	return targetFormat
+// 按 dayjs 的格式化标记输出，locale 为空时使用 Dayjs.Locale
+func formatTime(goTime time.Time, format string, locale string) string {
+	if format == "" {
+		format = defaultDayjsFormat
+	}
+	if locale == "" {
+		locale = Dayjs.Locale
+	}
+	lang := getDayjsLocale(locale)
+	return formatTokenPattern.ReplaceAllStringFunc(format, func(token string) string {
+		if strings.HasPrefix(token, "[") {
+			return token[1 : len(token)-1]
+		}
+		return formatToken(goTime, token, lang)
+	})
+}
+
+func formatToken(goTime time.Time, token string, lang *dayjsLocale) string {
+	hour12 := goTime.Hour() % 12
+	if hour12 == 0 {
+		hour12 = 12
+	}
+	switch token {
+	case "YY":
+		return fmt.Sprintf("%02d", goTime.Year()%100)
+	case "YYYY":
+		return fmt.Sprintf("%04d", goTime.Year())
+	case "M":
+		return strconv.Itoa(int(goTime.Month()))
+	case "MM":
+		return fmt.Sprintf("%02d", int(goTime.Month()))
+	case "MMM":
+		return lang.monthsShort[goTime.Month()-1]
+	case "MMMM":
+		return lang.months[goTime.Month()-1]
+	case "D":
+		return strconv.Itoa(goTime.Day())
+	case "DD":
+		return fmt.Sprintf("%02d", goTime.Day())
+	case "Do":
+		return lang.ordinal(goTime.Day())
+	case "d":
+		return strconv.Itoa(int(goTime.Weekday()))
+	case "dd":
+		return lang.weekdaysMin[goTime.Weekday()]
+	case "ddd":
+		return lang.weekdaysShort[goTime.Weekday()]
+	case "dddd":
+		return lang.weekdays[goTime.Weekday()]
+	case "H":
+		return strconv.Itoa(goTime.Hour())
+	case "HH":
+		return fmt.Sprintf("%02d", goTime.Hour())
+	case "h":
+		return strconv.Itoa(hour12)
+	case "hh":
+		return fmt.Sprintf("%02d", hour12)
+	case "a":
+		return lang.meridiem(goTime.Hour(), goTime.Minute(), true)
+	case "A":
+		return lang.meridiem(goTime.Hour(), goTime.Minute(), false)
+	case "m":
+		return strconv.Itoa(goTime.Minute())
+	case "mm":
+		return fmt.Sprintf("%02d", goTime.Minute())
+	case "s":
+		return strconv.Itoa(goTime.Second())
+	case "ss":
+		return fmt.Sprintf("%02d", goTime.Second())
+	case "SSS":
+		return fmt.Sprintf("%03d", goTime.Nanosecond()/int(time.Millisecond))
+	case "Z":
+		return goTime.Format("-07:00")
+	case "ZZ":
+		return goTime.Format("-0700")
+	case "Q":
+		return strconv.Itoa((int(goTime.Month())-1)/3 + 1)
+	case "X":
+		return strconv.FormatInt(goTime.Unix(), 10)
+	case "x":
+		return strconv.FormatInt(goTime.UnixMilli(), 10)
+	}
+	return token
 }
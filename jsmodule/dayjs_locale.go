@@ -0,0 +1,88 @@
+package jsmodule
+
+import (
+	"strconv"
+	"strings"
+)
+
+// dayjsLocale 日期的语言配置，与 dayjs 的 locale 对应
+type dayjsLocale struct {
+	months        []string
+	monthsShort   []string
+	weekdays      []string
+	weekdaysShort []string
+	weekdaysMin   []string
+	weekStart     int                                       // 一周的第一天，0为周日
+	meridiem      func(hour, minute int, lower bool) string // A/a
+	ordinal       func(n int) string                        // Do
+}
+
+var dayjsLocales = map[string]*dayjsLocale{
+	"en": {
+		months:        []string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		monthsShort:   []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		weekdays:      []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		weekdaysShort: []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+		weekdaysMin:   []string{"Su", "Mo", "Tu", "We", "Th", "Fr", "Sa"},
+		weekStart:     0,
+		meridiem: func(hour, minute int, lower bool) string {
+			m := "AM"
+			if hour >= 12 {
+				m = "PM"
+			}
+			if lower {
+				return strings.ToLower(m)
+			}
+			return m
+		},
+		ordinal: func(n int) string {
+			suffix := "th"
+			if n%100 < 11 || n%100 > 13 {
+				switch n % 10 {
+				case 1:
+					suffix = "st"
+				case 2:
+					suffix = "nd"
+				case 3:
+					suffix = "rd"
+				}
+			}
+			return strconv.Itoa(n) + suffix
+		},
+	},
+	"zh-cn": {
+		months:        []string{"一月", "二月", "三月", "四月", "五月", "六月", "七月", "八月", "九月", "十月", "十一月", "十二月"},
+		monthsShort:   []string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		weekdays:      []string{"星期日", "星期一", "星期二", "星期三", "星期四", "星期五", "星期六"},
+		weekdaysShort: []string{"周日", "周一", "周二", "周三", "周四", "周五", "周六"},
+		weekdaysMin:   []string{"日", "一", "二", "三", "四", "五", "六"},
+		weekStart:     1,
+		meridiem: func(hour, minute int, lower bool) string {
+			hm := hour*100 + minute
+			switch {
+			case hm < 600:
+				return "凌晨"
+			case hm < 900:
+				return "早上"
+			case hm < 1100:
+				return "上午"
+			case hm < 1300:
+				return "中午"
+			case hm < 1800:
+				return "下午"
+			}
+			return "晚上"
+		},
+		ordinal: func(n int) string {
+			return strconv.Itoa(n) + "日"
+		},
+	},
+}
+
+// 获取语言配置，名称不区分大小写，未知的语言使用 en
+func getDayjsLocale(name string) *dayjsLocale {
+	if locale, ok := dayjsLocales[strings.ToLower(strings.ReplaceAll(name, "_", "-"))]; ok {
+		return locale
+	}
+	return dayjsLocales["en"]
+}
@@ -0,0 +1,161 @@
+package jsmodule
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// 临时设置默认时区
+func withLocation(t *testing.T, zone string) {
+	t.Helper()
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := Dayjs.Location
+	Dayjs.Location = loc
+	t.Cleanup(func() { Dayjs.Location = old })
+}
+
+func TestDayjsTz(t *testing.T) {
+	tz := dayjsModule["tz"].(func(interface{}, string) (string, error))
+	tests := []struct {
+		name     string
+		datetime interface{}
+		zone     string
+		want     string
+	}{
+		{"before spring forward", "2024-03-10T06:59:59Z", "America/New_York", "2024-03-10T01:59:59.000-05:00"},
+		{"after spring forward", "2024-03-10T07:00:00Z", "America/New_York", "2024-03-10T03:00:00.000-04:00"},
+		{"before fall back", "2024-11-03T05:30:00Z", "America/New_York", "2024-11-03T01:30:00.000-04:00"},
+		{"after fall back", "2024-11-03T06:30:00Z", "America/New_York", "2024-11-03T01:30:00.000-05:00"},
+		{"naive summer", "2024-07-01 12:00:00", "America/New_York", "2024-07-01T12:00:00.000-04:00"},
+		{"naive winter", "2024-01-01 12:00:00", "America/New_York", "2024-01-01T12:00:00.000-05:00"},
+		{"no dst", "2024-07-01 12:00:00", "Asia/Shanghai", "2024-07-01T12:00:00.000+08:00"},
+		{"timestamp", int64(1704067200000), "Asia/Shanghai", "2024-01-01T08:00:00.000+08:00"},
+		{"southern hemisphere", "2024-01-01T00:00:00Z", "Australia/Sydney", "2024-01-01T11:00:00.000+11:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tz(tt.datetime, tt.zone)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("tz(%v, %s) = %s, want %s", tt.datetime, tt.zone, got, tt.want)
+			}
+		})
+	}
+	if _, err := tz("2024-01-01", "Nowhere/Unknown"); err == nil {
+		t.Fatal("unknown zone returned no error")
+	}
+}
+
+func TestDayjsDST(t *testing.T) {
+	withLocation(t, "America/New_York")
+	add := dayjsModule["add"].(func(interface{}, int, string) (interface{}, error))
+	diff := dayjsModule["diff"].(func(interface{}, interface{}, string, bool) (interface{}, error))
+	startOf := dayjsModule["startOf"].(func(interface{}, string) (interface{}, error))
+
+	addTests := []struct {
+		name     string
+		datetime string
+		n        int
+		unit     string
+		want     string
+	}{
+		{"day keeps wall clock", "2024-03-09T12:00:00-05:00", 1, "day", "2024-03-10T12:00:00-04:00"},
+		{"hours are elapsed time", "2024-03-09T12:00:00-05:00", 24, "hour", "2024-03-10T13:00:00-04:00"},
+		{"fall back day", "2024-11-02 12:00:00", 1, "day", "2024-11-03 12:00:00"},
+		{"month", "2024-02-10T12:00:00-05:00", 1, "month", "2024-03-10T12:00:00-04:00"},
+	}
+	for _, tt := range addTests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := add(tt.datetime, tt.n, tt.unit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("add(%s, %d, %s) = %v, want %s", tt.datetime, tt.n, tt.unit, got, tt.want)
+			}
+		})
+	}
+
+	diffTests := []struct {
+		name string
+		a, b string
+		unit string
+		want int64
+	}{
+		{"short day", "2024-03-11 00:00:00", "2024-03-10 00:00:00", "day", 1},
+		{"short day hours", "2024-03-11 00:00:00", "2024-03-10 00:00:00", "hour", 23},
+		{"long day", "2024-11-04 00:00:00", "2024-11-03 00:00:00", "day", 1},
+		{"long day hours", "2024-11-04 00:00:00", "2024-11-03 00:00:00", "hour", 25},
+		{"week across dst", "2024-03-14 00:00:00", "2024-03-07 00:00:00", "week", 1},
+	}
+	for _, tt := range diffTests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := diff(tt.a, tt.b, tt.unit, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("diff(%s, %s, %s) = %v, want %d", tt.a, tt.b, tt.unit, got, tt.want)
+			}
+		})
+	}
+
+	got, err := startOf("2024-03-10T15:00:00-04:00", "day")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "2024-03-10T00:00:00-05:00" {
+		t.Fatalf("startOf day = %v", got)
+	}
+}
+
+func TestDayjsParse(t *testing.T) {
+	withLocation(t, "Asia/Shanghai")
+	tests := []struct {
+		name   string
+		value  string
+		format string
+		zone   string
+		want   string
+	}{
+		{"day first", "31/01/2024", "DD/MM/YYYY", "", "2024-01-31T00:00:00.000+08:00"},
+		{"with zone", "31/01/2024 09:30", "DD/MM/YYYY HH:mm", "America/New_York", "2024-01-31T09:30:00.000-05:00"},
+		{"dst zone", "2024-07-01 09:30", "YYYY-MM-DD HH:mm", "America/New_York", "2024-07-01T09:30:00.000-04:00"},
+		{"offset kept", "2024-01-31T09:30:00+02:00", "YYYY-MM-DDTHH:mm:ssZ", "", "2024-01-31T09:30:00.000+02:00"},
+		{"month name", "Jan 5 2024", "MMM D YYYY", "", "2024-01-05T00:00:00.000+08:00"},
+		{"unix seconds", "1704067200", "X", "", "2024-01-01T08:00:00.000+08:00"},
+		{"auto", "2024-01-31 09:30:00", "", "", "2024-01-31T09:30:00.000+08:00"},
+		{"bracketed literal", "Day 5 of January 2024", "[Day] D [of] MMMM YYYY", "", "2024-01-05T00:00:00.000+08:00"},
+		{"literal with layout digits", "2024-03-05 shift 2", "YYYY-MM-DD [shift 2]", "", "2024-03-05T00:00:00.000+08:00"},
+		{"literal with layout names", "Jan Mon 2024-03-05", "[Jan Mon] YYYY-MM-DD", "", "2024-03-05T00:00:00.000+08:00"},
+		{"plain literal", "2024.03.05 at 7pm", "YYYY.MM.DD [at] ha", "", "2024-03-05T19:00:00.000+08:00"},
+		{"compact", "20240305T0730", "YYYYMMDDTHHmm", "", "2024-03-05T07:30:00.000+08:00"},
+	}
+	parse := dayjsModule["parse"].(func(string, string, string) (string, error))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parse(tt.value, tt.format, tt.zone)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("parse(%s, %s) = %s, want %s", tt.value, tt.format, got, tt.want)
+			}
+		})
+	}
+	for _, tt := range []struct{ value, format string }{
+		{"2024", "YYYY Qo"},
+		{"2024-03-05 shift 3", "YYYY-MM-DD [shift 2]"},
+		{"2024-13-05", "YYYY-MM-DD"},
+	} {
+		if got, err := parse(tt.value, tt.format, ""); err == nil {
+			t.Fatalf("parse(%s, %s) = %s, want error", tt.value, tt.format, got)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package jsmodule
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/skyfox2000/nect-utils/json"
+	"github.com/skyfox2000/nect-utils/underscore"
+)
+
+// HTTP http 模块的配置
+var HTTP = &httpStruct{}
+
+// 默认响应体大小上限 10MB
+const defaultMaxResponseSize = 10 << 20
+
+type httpStruct struct {
+	Transport       http.RoundTripper // 为 nil 时使用 http.DefaultTransport，测试时可替换
+	AllowHosts      []string          // 允许访问的主机，如 "api.local"、"api.local:8080"、"*.local"，"*" 表示不限制；为空时禁止所有请求
+	MaxResponseSize int64             // 响应体大小上限（字节），默认10MB
+	Timeout         time.Duration     // 单个请求的默认超时，0表示只受脚本执行超时限制
+}
+
+// 脚本中的请求参数：{method, url, headers, body, timeout}，timeout 单位为毫秒
+type httpRequest struct {
+	method  string
+	url     string
+	headers map[string]interface{}
+	body    interface{}
+	timeout time.Duration
+}
+
+func newHTTPModule(mc *ModuleContext) interface{} {
+	return map[string]interface{}{
+		"request": func(options map[string]interface{}) goja.Value {
+			req := httpRequestFromOptions(options)
+			return mc.Promise(func(ctx context.Context) (interface{}, error) {
+				return HTTP.Do(ctx, req.method, req.url, req.headers, req.body, req.timeout)
+			})
+		},
+		"get": func(rawURL string, options map[string]interface{}) goja.Value {
+			req := httpRequestFromOptions(options)
+			return mc.Promise(func(ctx context.Context) (interface{}, error) {
+				return HTTP.Do(ctx, http.MethodGet, rawURL, req.headers, nil, req.timeout)
+			})
+		},
+		"post": func(rawURL string, body interface{}, options map[string]interface{}) goja.Value {
+			req := httpRequestFromOptions(options)
+			return mc.Promise(func(ctx context.Context) (interface{}, error) {
+				return HTTP.Do(ctx, http.MethodPost, rawURL, req.headers, body, req.timeout)
+			})
+		},
+	}
+}
+
+func httpRequestFromOptions(options map[string]interface{}) httpRequest {
+	req := httpRequest{method: http.MethodGet}
+	if method, ok := options["method"].(string); ok && method != "" {
+		req.method = strings.ToUpper(method)
+	}
+	req.url, _ = options["url"].(string)
+	req.headers, _ = options["headers"].(map[string]interface{})
+	req.body = options["body"]
+	switch t := options["timeout"].(type) {
+	case int64:
+		req.timeout = time.Duration(t) * time.Millisecond
+	case float64:
+		req.timeout = time.Duration(t * float64(time.Millisecond))
+	}
+	return req
+}
+
+// Do 发送请求，返回 {status, statusText, headers, body, json}
+// body 为字符串或 []byte 时原样发送，其他类型转换为 JSON 并设置 Content-Type；响应为 JSON 时解析到 json 字段
+// 非 2xx 的响应不作为错误，由脚本根据 status 判断
+func (p *httpStruct) Do(ctx context.Context, method, rawURL string, headers map[string]interface{}, body interface{}, timeout time.Duration) (map[string]interface{}, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, errors.New("http url must start with http:// or https://: " + rawURL)
+	}
+	if err = p.checkHost(target); err != nil {
+		return nil, err
+	}
+
+	if timeout <= 0 {
+		timeout = p.Timeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var reader io.Reader
+	isJSON := false
+	switch t := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(t)
+	case []byte:
+		reader = bytes.NewReader(t)
+	default:
+		if underscore.Underscore.IsObject(t) {
+			reader = strings.NewReader(json.JSON.Stringify(t))
+		} else {
+			reader = strings.NewReader(toHeaderValue(t))
+		}
+		isJSON = true
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	if isJSON {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, toHeaderValue(v))
+	}
+
+	client := &http.Client{
+		Transport: p.Transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return p.checkHost(req.URL)
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	maxSize := p.MaxResponseSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxResponseSize
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, errors.New("http response exceeds " + strconv.FormatInt(maxSize, 10) + " bytes: " + rawURL)
+	}
+
+	respHeaders := make(map[string]interface{}, len(resp.Header))
+	for k, v := range resp.Header {
+		respHeaders[strings.ToLower(k)] = strings.Join(v, ", ")
+	}
+	result := map[string]interface{}{
+		"status":     resp.StatusCode,
+		"statusText": http.StatusText(resp.StatusCode),
+		"headers":    respHeaders,
+		"body":       string(data),
+		"json":       nil,
+	}
+	if strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		if parsed, ok := json.JSON.Parse(data); ok {
+			result["json"] = parsed
+		}
+	}
+	return result, nil
+}
+
+// 检查主机是否在白名单中
+func (p *httpStruct) checkHost(target *url.URL) error {
+	hostname := strings.ToLower(target.Hostname())
+	host := hostname
+	if port := target.Port(); port != "" {
+		host = net.JoinHostPort(hostname, port)
+	}
+	for _, allow := range p.AllowHosts {
+		allow = strings.ToLower(allow)
+		switch {
+		case allow == "*", allow == hostname, allow == host:
+			return nil
+		case strings.HasPrefix(allow, "*.") && strings.HasSuffix(hostname, allow[1:]):
+			return nil
+		}
+	}
+	return errors.New("http host is not allowed: " + host)
+}
+
+func toHeaderValue(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case int64:
+		return strconv.FormatInt(t, 10)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	}
+	if underscore.Underscore.IsObject(v) {
+		return json.JSON.Stringify(v)
+	}
+	return fmt.Sprint(v)
+}
+
+func init() {
+	DefaultRegistry.Register("http", newHTTPModule)
+}
@@ -0,0 +1,117 @@
+package jsmodule
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPAllowHosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	tests := []struct {
+		name    string
+		allow   []string
+		url     string
+		wantErr bool
+	}{
+		{"empty allowlist", nil, server.URL, true},
+		{"any host", []string{"*"}, server.URL, false},
+		{"host and port", []string{host}, server.URL, false},
+		{"hostname", []string{"127.0.0.1"}, server.URL, false},
+		{"other port", []string{"127.0.0.1:1"}, server.URL, true},
+		{"other host", []string{"api.local"}, server.URL, true},
+		{"scheme", []string{"*"}, "file:///etc/passwd", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &httpStruct{AllowHosts: tt.allow}
+			result, err := client.Do(context.Background(), http.MethodGet, tt.url, nil, nil, 0)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Do error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (result["status"] != http.StatusOK || result["json"] == nil) {
+				t.Fatalf("result = %#v", result)
+			}
+		})
+	}
+}
+
+func TestHTTPCheckHost(t *testing.T) {
+	client := &httpStruct{AllowHosts: []string{"*.local", "API.example.com"}}
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{"http://a.local/x", true},
+		{"http://a.b.local:8080/x", true},
+		{"http://local/x", false},
+		{"http://evillocal/x", false},
+		{"https://api.example.com/x", true},
+		{"https://example.com/x", false},
+	}
+	for _, tt := range tests {
+		target, _ := url.Parse(tt.url)
+		if err := client.checkHost(target); (err == nil) != tt.allowed {
+			t.Fatalf("checkHost(%s) = %v, want allowed %v", tt.url, err, tt.allowed)
+		}
+	}
+}
+
+func TestHTTPRedirectToDisallowedHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://blocked.example/", http.StatusFound)
+	}))
+	defer server.Close()
+	client := &httpStruct{AllowHosts: []string{"127.0.0.1"}}
+	if _, err := client.Do(context.Background(), http.MethodGet, server.URL, nil, nil, 0); err == nil {
+		t.Fatal("redirect to a disallowed host succeeded")
+	}
+}
+
+func TestHTTPLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-time.After(2 * time.Second):
+			case <-r.Context().Done():
+			}
+		}
+		w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		client  *httpStruct
+		path    string
+		timeout time.Duration
+		wantErr bool
+	}{
+		{"within limits", &httpStruct{AllowHosts: []string{"*"}, MaxResponseSize: 100}, "/", 0, false},
+		{"response too large", &httpStruct{AllowHosts: []string{"*"}, MaxResponseSize: 99}, "/", 0, true},
+		{"default timeout", &httpStruct{AllowHosts: []string{"*"}, Timeout: 50 * time.Millisecond}, "/slow", 0, true},
+		{"request timeout", &httpStruct{AllowHosts: []string{"*"}, Timeout: time.Minute}, "/slow", 50 * time.Millisecond, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			_, err := tt.client.Do(context.Background(), http.MethodGet, server.URL+tt.path, nil, nil, tt.timeout)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Do error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("request took %v", elapsed)
+			}
+		})
+	}
+}
@@ -14,8 +14,42 @@ var jsonModule = map[string]interface{}{
 		result, _ := JSON.GetXPathValue(obj, xpath)
 		return result
 	},
-	"parse": func(jsonStr interface{}) interface{} {
+	// JSONPath 查询，返回所有匹配的值
+	"query": func(obj interface{}, path string) ([]interface{}, error) {
 		JSON := json.JSON
+		return JSON.Query(obj, path)
+	},
+	// 生成 JSON Patch（RFC 6902）
+	"diff": func(a, b interface{}) []interface{} {
+		JSON := json.JSON
+		return JSON.Diff(a, b)
+	},
+	"applyPatch": func(doc interface{}, patch interface{}) (interface{}, error) {
+		JSON := json.JSON
+		return JSON.ApplyPatch(doc, patch)
+	},
+	// 合并数据（RFC 7386）
+	"mergePatch": func(target, patch interface{}) interface{} {
+		JSON := json.JSON
+		return JSON.MergePatch(target, patch)
+	},
+	// 渲染模板 ${path | default:'x' | upper}，escape 为 ""、json、sql、url、html
+	"render": func(template string, data interface{}, escape string, mustHas bool) (string, error) {
+		JSON := json.JSON
+		return JSON.Render(template, data, escape, mustHas)
+	},
+	// options.useNumber 为 true 时保留数字精度：超出安全范围的整数与超过15位有效数字的小数返回字符串（不支持 BigInt）
+	"parse": func(jsonStr interface{}, options map[string]interface{}) interface{} {
+		JSON := json.JSON
+		if useNumber, _ := options["useNumber"].(bool); useNumber {
+			result, ok := JSON.Parse(jsonStr, json.UseNumber())
+			if !ok {
+				Logger.Error("failed to parse json: " + jsonStr.(string))
+				return result
+			}
+			result, _ = JSON.SafeNumbers(result, true)
+			return result
+		}
 		result, ok := JSON.Parse(jsonStr)
 		if !ok {
 			Logger.Error("failed to parse json: " + jsonStr.(string))
@@ -29,5 +63,5 @@ var jsonModule = map[string]interface{}{
 }
 
 func init() {
-	JSModules["JSON"] = jsonModule
+	registerStatic("JSON", jsonModule)
 }
@@ -0,0 +1,231 @@
+package jsmodule
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dop251/goja"
+	utils "github.com/skyfox2000/nect-utils"
+)
+
+// ModuleContext 模块实例化时的上下文
+type ModuleContext struct {
+	Runtime *goja.Runtime
+	Tool    utils.UtilsTool
+	Async   func(work AsyncWork) goja.Value // 由 jsrun 提供，模块通过 Promise 方法使用
+}
+
+// AsyncWork 异步宿主任务，在协程池中执行，不能访问 Runtime
+// ctx 在脚本执行结束、超时或取消时结束，耗时的 I/O 应监听 ctx
+type AsyncWork func(ctx context.Context) (interface{}, error)
+
+// Promise 用于实现异步宿主函数：work 在协程池中执行，返回的 Promise 在事件循环中以 work 的结果完成
+// 必须在宿主函数内（脚本所在协程）调用，如:
+//
+//	"getAsync": func(key string) goja.Value {
+//		return mc.Promise(func(ctx context.Context) (interface{}, error) { ... })
+//	}
+func (mc *ModuleContext) Promise(work AsyncWork) goja.Value {
+	if mc.Async != nil {
+		return mc.Async(work)
+	}
+	// 不在 jsrun 中使用时同步执行
+	promise, resolve, reject := mc.Runtime.NewPromise()
+	result, err := work(context.Background())
+	if err != nil {
+		reject(mc.Runtime.NewGoError(err))
+	} else {
+		resolve(result)
+	}
+	return mc.Runtime.ToValue(promise)
+}
+
+// ModuleFactory 创建模块实例，每次执行中首次 require 该模块时调用
+type ModuleFactory func(mc *ModuleContext) interface{}
+
+type moduleEntry struct {
+	name    string
+	version string
+	factory ModuleFactory
+}
+
+// Registry 模块注册表，支持同名模块的多个版本
+type Registry struct {
+	mutex   sync.RWMutex
+	modules map[string][]*moduleEntry // 按版本从高到低排列
+}
+
+// DefaultRegistry 默认模块注册表，jsrun 的 require 从这里加载模块
+var DefaultRegistry = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{
+		modules: make(map[string][]*moduleEntry),
+	}
+}
+
+// Register 注册模块，name 可带版本号，如 "dayjs@2.0.0"
+// 同名同版本重复注册时覆盖原有模块
+func (r *Registry) Register(name string, factory ModuleFactory) {
+	moduleName, version := splitModuleName(name)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entries := r.modules[moduleName]
+	for i, entry := range entries {
+		if entry.version == version {
+			entries[i] = &moduleEntry{name: moduleName, version: version, factory: factory}
+			return
+		}
+	}
+	entries = append(entries, &moduleEntry{name: moduleName, version: version, factory: factory})
+	sort.SliceStable(entries, func(i, j int) bool {
+		return compareVersion(entries[i].version, entries[j].version) > 0
+	})
+	r.modules[moduleName] = entries
+}
+
+// Unregister 删除模块，name 不带版本号时删除所有版本
+func (r *Registry) Unregister(name string) {
+	moduleName, version := splitModuleName(name)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if version == "" {
+		delete(r.modules, moduleName)
+		return
+	}
+	entries := r.modules[moduleName]
+	for i, entry := range entries {
+		if entry.version == version {
+			r.modules[moduleName] = append(entries[:i:i], entries[i+1:]...)
+			break
+		}
+	}
+}
+
+// Names 返回所有已注册的模块，带版本的模块格式为 "name@version"
+func (r *Registry) Names() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	names := make([]string, 0, len(r.modules))
+	for name, entries := range r.modules {
+		for _, entry := range entries {
+			names = append(names, joinModuleName(name, entry.version))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Available 返回 allow 白名单内可用的模块，allow 为空时返回全部
+func (r *Registry) Available(allow []string) []string {
+	names := make([]string, 0)
+	for _, name := range r.Names() {
+		moduleName, version := splitModuleName(name)
+		if isAllowed(moduleName, version, allow) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Resolve 根据 require 的名称查找模块
+// 未指定版本时使用白名单允许的最高版本，"dayjs@2" 匹配 2.x 的最高版本，返回的 key 为 "name@version"，可用于缓存模块实例
+func (r *Registry) Resolve(spec string, allow []string) (string, ModuleFactory, error) {
+	moduleName, version := splitModuleName(spec)
+	r.mutex.RLock()
+	entries := r.modules[moduleName]
+	var found *moduleEntry
+	for _, entry := range entries {
+		matched := version == "" || entry.version == version || strings.HasPrefix(entry.version, version+".")
+		if matched && isAllowed(entry.name, entry.version, allow) {
+			found = entry
+			break
+		}
+	}
+	r.mutex.RUnlock()
+
+	if found == nil {
+		return "", nil, errors.New("require module not found: " + spec +
+			", available modules: " + strings.Join(r.Available(allow), ", "))
+	}
+	return joinModuleName(found.name, found.version), found.factory, nil
+}
+
+// Static 将固定的模块对象包装为 ModuleFactory，每次实例化返回浅拷贝，避免脚本修改共享的模块对象
+func Static(module map[string]interface{}) ModuleFactory {
+	return func(mc *ModuleContext) interface{} {
+		instance := make(map[string]interface{}, len(module))
+		for k, v := range module {
+			instance[k] = v
+		}
+		return instance
+	}
+}
+
+// 白名单项可以是 "name" 或 "name@version"
+func isAllowed(name, version string, allow []string) bool {
+	if len(allow) == 0 {
+		return true
+	}
+	for _, item := range allow {
+		allowName, allowVersion := splitModuleName(item)
+		if allowName == name && (allowVersion == "" || allowVersion == version) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitModuleName(spec string) (string, string) {
+	spec = strings.TrimSpace(spec)
+	if index := strings.LastIndex(spec, "@"); index > 0 {
+		return spec[:index], spec[index+1:]
+	}
+	return spec, ""
+}
+
+func joinModuleName(name, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + "@" + version
+}
+
+// 按数字逐段比较版本号，非数字段按字符串比较
+func compareVersion(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y string
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		xn, errX := strconv.Atoi(x)
+		yn, errY := strconv.Atoi(y)
+		switch {
+		case errX == nil && errY == nil:
+			if xn != yn {
+				if xn > yn {
+					return 1
+				}
+				return -1
+			}
+		case x != y:
+			if x > y {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package jsmodule
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestRegistry() *Registry {
+	r := NewRegistry()
+	for _, name := range []string{"lib@1.9.0", "lib@1.10.0", "lib@2.0.0", "lib@2.1.3", "plain"} {
+		version := name
+		r.Register(name, func(mc *ModuleContext) interface{} { return version })
+	}
+	return r
+}
+
+func TestRegistryResolve(t *testing.T) {
+	r := newTestRegistry()
+	tests := []struct {
+		name    string
+		spec    string
+		allow   []string
+		wantKey string
+		wantErr bool
+	}{
+		{"latest", "lib", nil, "lib@2.1.3", false},
+		{"exact", "lib@1.9.0", nil, "lib@1.9.0", false},
+		{"major", "lib@1", nil, "lib@1.10.0", false},
+		{"minor", "lib@2.0", nil, "lib@2.0.0", false},
+		{"prefix is not a version", "lib@2.1.", nil, "", true},
+		{"allowed name", "lib", []string{"lib"}, "lib@2.1.3", false},
+		{"allowed version", "lib", []string{"lib@1.9.0"}, "lib@1.9.0", false},
+		{"version not allowed", "lib@2", []string{"lib@1.9.0"}, "", true},
+		{"no version", "plain", nil, "plain", false},
+		{"not allowed", "plain", []string{"lib"}, "", true},
+		{"not registered", "missing", nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, factory, err := r.Resolve(tt.spec, tt.allow)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve(%s) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if key != tt.wantKey || factory(nil) != tt.wantKey {
+				t.Fatalf("Resolve(%s) = %s, %v, want %s", tt.spec, key, factory(nil), tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestRegistryNotAllowedError(t *testing.T) {
+	r := newTestRegistry()
+	_, _, err := r.Resolve("plain", []string{"lib@1.9.0", "lib@2.0.0"})
+	if err == nil {
+		t.Fatal("module outside the allowlist resolved")
+	}
+	// 错误中只列出白名单内的模块
+	if want := "available modules: lib@1.9.0, lib@2.0.0"; !strings.HasSuffix(err.Error(), want) {
+		t.Fatalf("error = %q, want suffix %q", err, want)
+	}
+}
+
+func TestRegistryRegister(t *testing.T) {
+	r := newTestRegistry()
+	r.Register("lib@2.1.3", func(mc *ModuleContext) interface{} { return "replaced" })
+	if _, factory, _ := r.Resolve("lib", nil); factory(nil) != "replaced" {
+		t.Fatal("registering the same version did not replace the module")
+	}
+	r.Unregister("lib@2.1.3")
+	if key, _, _ := r.Resolve("lib", nil); key != "lib@2.0.0" {
+		t.Fatalf("after Unregister(lib@2.1.3) Resolve(lib) = %s, want lib@2.0.0", key)
+	}
+	r.Unregister("lib")
+	if got, want := r.Names(), []string{"plain"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Names() = %v, want %v", got, want)
+	}
+}
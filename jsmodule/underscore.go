@@ -23,5 +23,5 @@ func registerUnderscore() map[string]interface{} {
 }
 
 func init() {
-	JSModules["underscore"] = registerUnderscore()
+	registerStatic("underscore", registerUnderscore())
 }
@@ -16,5 +16,5 @@ var zipModule = map[string]interface{}{
 }
 
 func init() {
-	JSModules["ZIP"] = zipModule
+	registerStatic("ZIP", zipModule)
 }
@@ -0,0 +1,100 @@
+package json
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// 按结构深拷贝，不经过序列化，数值等类型保持不变
+// map、slice、数组、结构体及其中的指针和 *sync.Map 都会复制，函数和通道共用
+func cloneValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, string, bool, float64, int, int64, time.Time:
+		return v
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = cloneValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = cloneValue(item)
+		}
+		return result
+	}
+	return cloneReflect(reflect.ValueOf(value), map[uintptr]reflect.Value{}).Interface()
+}
+
+var syncMapType = reflect.TypeOf(&sync.Map{})
+
+// seen 记录已复制的指针，保证循环引用只复制一次
+func cloneReflect(value reflect.Value, seen map[uintptr]reflect.Value) reflect.Value {
+	switch value.Kind() {
+	case reflect.Interface:
+		if value.IsNil() {
+			return value
+		}
+		result := reflect.New(value.Type()).Elem()
+		result.Set(cloneReflect(value.Elem(), seen))
+		return result
+	case reflect.Ptr:
+		if value.IsNil() {
+			return value
+		}
+		if cloned, ok := seen[value.Pointer()]; ok {
+			return cloned
+		}
+		if value.Type() == syncMapType {
+			result := &sync.Map{}
+			seen[value.Pointer()] = reflect.ValueOf(result)
+			value.Interface().(*sync.Map).Range(func(key, item interface{}) bool {
+				result.Store(key, cloneValue(item))
+				return true
+			})
+			return reflect.ValueOf(result)
+		}
+		result := reflect.New(value.Type().Elem())
+		seen[value.Pointer()] = result
+		result.Elem().Set(cloneReflect(value.Elem(), seen))
+		return result
+	case reflect.Map:
+		if value.IsNil() {
+			return value
+		}
+		result := reflect.MakeMapWithSize(value.Type(), value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			result.SetMapIndex(iter.Key(), cloneReflect(iter.Value(), seen))
+		}
+		return result
+	case reflect.Slice:
+		if value.IsNil() {
+			return value
+		}
+		result := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for i := 0; i < value.Len(); i++ {
+			result.Index(i).Set(cloneReflect(value.Index(i), seen))
+		}
+		return result
+	case reflect.Array:
+		result := reflect.New(value.Type()).Elem()
+		for i := 0; i < value.Len(); i++ {
+			result.Index(i).Set(cloneReflect(value.Index(i), seen))
+		}
+		return result
+	case reflect.Struct:
+		// 先整体复制（包括未导出字段），再深拷贝可导出的字段
+		result := reflect.New(value.Type()).Elem()
+		result.Set(value)
+		for i := 0; i < value.NumField(); i++ {
+			if field := result.Field(i); field.CanSet() {
+				field.Set(cloneReflect(value.Field(i), seen))
+			}
+		}
+		return result
+	}
+	return value
+}
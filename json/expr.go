@@ -0,0 +1,1020 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// 表达式编译缓存的最大数量，超出时清空重建
+const maxExprCache = 1000
+
+var exprCache = make(map[string]exprNode)
+var exprCacheMutex = &sync.RWMutex{}
+
+// 计算表达式，变量以 $ 开头，对应 data 中的键，如 $order.items[0].price * 2
+// 支持：数字、字符串、true/false/null、数组与对象字面量，+ - * / %，比较，&& || !，三元 ? :，
+// .name 与 [index] 取值，以及白名单函数 len、sum、min、max、avg、abs、round、floor、ceil、
+// contains、lower、upper、trim、join、keys；不能执行任意脚本，编译后的表达式会被缓存
+// 运算结果与 JavaScript 一致（== 的类型转换、字符串拼接、NaN 等），data 中不存在的变量和在 null 上取值返回错误；
+// 对象之间的 == 与 === 在 JavaScript 中比较引用，这里无法对应，同样返回错误
+func (p *jsonStruct) Evaluate(expression string, data map[string]interface{}) (interface{}, error) {
+	node, err := compileExpr(expression)
+	if err != nil {
+		return nil, err
+	}
+	return node.eval(data)
+}
+
+func compileExpr(expression string) (exprNode, error) {
+	exprCacheMutex.RLock()
+	node, ok := exprCache[expression]
+	exprCacheMutex.RUnlock()
+	if ok {
+		return node, nil
+	}
+
+	parser := &exprParser{src: expression}
+	node, err := parser.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	exprCacheMutex.Lock()
+	if len(exprCache) >= maxExprCache {
+		exprCache = make(map[string]exprNode)
+	}
+	exprCache[expression] = node
+	exprCacheMutex.Unlock()
+	return node, nil
+}
+
+// ==== 求值 ====
+
+type exprNode interface {
+	eval(data map[string]interface{}) (interface{}, error)
+}
+
+type exprLiteral struct {
+	value interface{}
+}
+
+func (e exprLiteral) eval(data map[string]interface{}) (interface{}, error) {
+	return e.value, nil
+}
+
+type exprVariable struct {
+	name string
+}
+
+func (e exprVariable) eval(data map[string]interface{}) (interface{}, error) {
+	value, ok := data[e.name]
+	if !ok {
+		return nil, errors.New("$" + e.name + " is not defined")
+	}
+	return value, nil
+}
+
+// 数组字面量
+type exprArray struct {
+	items []exprNode
+}
+
+func (e exprArray) eval(data map[string]interface{}) (interface{}, error) {
+	result := make([]interface{}, len(e.items))
+	for i, item := range e.items {
+		value, err := item.eval(data)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = value
+	}
+	return result, nil
+}
+
+// 对象字面量
+type exprObject struct {
+	keys   []string
+	values []exprNode
+}
+
+func (e exprObject) eval(data map[string]interface{}) (interface{}, error) {
+	result := make(map[string]interface{}, len(e.keys))
+	for i, key := range e.keys {
+		value, err := e.values[i].eval(data)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// .name 或 [index] 取值，不存在时为 null；在 null 上取值时返回错误，optional（?.）时返回 null
+type exprMember struct {
+	object   exprNode
+	key      exprNode
+	optional bool
+}
+
+func (e exprMember) eval(data map[string]interface{}) (interface{}, error) {
+	object, err := e.object.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	key, err := e.key.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	if object == nil {
+		if e.optional {
+			return nil, nil
+		}
+		return nil, errors.New("cannot read property " + strconv.Quote(exprString(key)) + " of null")
+	}
+	if index, ok := toFloat(key); ok {
+		if list, ok := elementsOf(object); ok {
+			i := int(index)
+			if float64(i) != index || i < 0 || i >= len(list) {
+				return nil, nil
+			}
+			return list[i], nil
+		}
+		if s, ok := object.(string); ok {
+			runes := []rune(s)
+			i := int(index)
+			if float64(i) != index || i < 0 || i >= len(runes) {
+				return nil, nil
+			}
+			return string(runes[i]), nil
+		}
+		key = exprString(key)
+	}
+	name, ok := key.(string)
+	if !ok {
+		return nil, nil
+	}
+	if name == "length" {
+		if n, ok := exprLength(object); ok {
+			return int64(n), nil
+		}
+	}
+	value, _ := childByName(object, name)
+	return value, nil
+}
+
+type exprUnary struct {
+	op      string
+	operand exprNode
+}
+
+func (e exprUnary) eval(data map[string]interface{}) (interface{}, error) {
+	value, err := e.operand.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "!":
+		return !exprTruthy(value), nil
+	case "-":
+		return numberResult(-exprNumber(value)), nil
+	}
+	return numberResult(exprNumber(value)), nil
+}
+
+type exprBinary struct {
+	op          string
+	left, right exprNode
+}
+
+func (e exprBinary) eval(data map[string]interface{}) (interface{}, error) {
+	left, err := e.left.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	// && 和 || 短路求值，返回操作数本身
+	switch e.op {
+	case "&&":
+		if !exprTruthy(left) {
+			return left, nil
+		}
+		return e.right.eval(data)
+	case "||":
+		if exprTruthy(left) {
+			return left, nil
+		}
+		return e.right.eval(data)
+	}
+
+	right, err := e.right.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "==", "!=", "===", "!==":
+		var equal bool
+		if e.op == "==" || e.op == "!=" {
+			equal, err = looseEqual(left, right)
+		} else {
+			equal, err = strictEqual(left, right)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return equal == (e.op == "==" || e.op == "==="), nil
+	case "<", "<=", ">", ">=":
+		cmp, ok := jsCompare(left, right)
+		if !ok {
+			return false, nil
+		}
+		switch e.op {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		}
+		return cmp >= 0, nil
+	case "+":
+		// 对象与数组先转换为字符串，有字符串时拼接
+		left, right = toPrimitive(left), toPrimitive(right)
+		_, leftIsString := left.(string)
+		_, rightIsString := right.(string)
+		if leftIsString || rightIsString {
+			return exprString(left) + exprString(right), nil
+		}
+	}
+
+	l, r := exprNumber(left), exprNumber(right)
+	switch e.op {
+	case "+":
+		return numberResult(l + r), nil
+	case "-":
+		return numberResult(l - r), nil
+	case "*":
+		return numberResult(l * r), nil
+	case "/":
+		// 与 JS 一致，除以0得到 Infinity、-Infinity 或 NaN
+		return numberResult(l / r), nil
+	case "%":
+		return numberResult(math.Mod(l, r)), nil
+	}
+	return nil, errors.New("unknown operator " + e.op)
+}
+
+type exprTernary struct {
+	cond, then, otherwise exprNode
+}
+
+func (e exprTernary) eval(data map[string]interface{}) (interface{}, error) {
+	cond, err := e.cond.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	if exprTruthy(cond) {
+		return e.then.eval(data)
+	}
+	return e.otherwise.eval(data)
+}
+
+type exprCall struct {
+	name string
+	fn   exprFunc
+	args []exprNode
+}
+
+func (e exprCall) eval(data map[string]interface{}) (interface{}, error) {
+	args := make([]interface{}, len(e.args))
+	for i, arg := range e.args {
+		value, err := arg.eval(data)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+	result, err := e.fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", e.name, err)
+	}
+	return result, nil
+}
+
+// 与 JavaScript 相同：false、null、0、NaN、空字符串为假
+func exprTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return v != ""
+	case bool:
+		return v
+	case nil:
+		return false
+	}
+	if n, ok := toFloat(value); ok {
+		return n != 0 && !math.IsNaN(n)
+	}
+	return true
+}
+
+// 与 JavaScript 的 Number() 相同：null 为0，空字符串为0，无法转换的字符串与对象为 NaN，数组先转换为字符串
+func exprNumber(value interface{}) float64 {
+	switch v := toPrimitive(value).(type) {
+	case nil:
+		return 0
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case string:
+		s := strings.TrimSpace(v)
+		switch {
+		case s == "":
+			return 0
+		case s == "Infinity", s == "+Infinity":
+			return math.Inf(1)
+		case s == "-Infinity":
+			return math.Inf(-1)
+		case len(s) > 2 && s[0] == '0' && strings.IndexByte("xXoObB", s[1]) >= 0:
+			if n, err := strconv.ParseInt(s, 0, 64); err == nil {
+				return float64(n)
+			}
+			return math.NaN()
+		}
+		// ParseFloat 接受的 inf、nan、十六进制浮点数与 _ 分隔符在 JavaScript 中都不是数字
+		if strings.ContainsAny(s, "iInN_xXpP") {
+			return math.NaN()
+		}
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil && !errors.Is(err, strconv.ErrRange) {
+			return math.NaN()
+		}
+		return n
+	}
+	if n, ok := toFloat(value); ok {
+		return n
+	}
+	return math.NaN()
+}
+
+// 对象与数组转换为原始值（字符串），与 JavaScript 的默认转换相同
+func toPrimitive(value interface{}) interface{} {
+	switch value.(type) {
+	case nil, string, bool:
+		return value
+	}
+	if _, ok := toFloat(value); ok {
+		return value
+	}
+	return exprString(value)
+}
+
+// 是否为对象或数组
+func isExprObject(value interface{}) bool {
+	switch value.(type) {
+	case nil, string, bool:
+		return false
+	}
+	_, ok := toFloat(value)
+	return !ok
+}
+
+// 与 JavaScript 的 === 相同，对象之间比较引用，无法对应时返回错误
+func strictEqual(left, right interface{}) (bool, error) {
+	if isExprObject(left) && isExprObject(right) {
+		return false, errors.New("comparing objects is not supported")
+	}
+	_, leftIsNumber := toFloat(left)
+	_, rightIsNumber := toFloat(right)
+	if leftIsNumber && rightIsNumber {
+		return valueEqual(left, right), nil
+	}
+	if leftIsNumber || rightIsNumber {
+		return false, nil
+	}
+	return left == right, nil
+}
+
+// 与 JavaScript 的 == 相同：null 只等于 null，布尔值转换为数字，数字与字符串按数字比较，对象先转换为字符串
+func looseEqual(left, right interface{}) (bool, error) {
+	if left == nil || right == nil {
+		return left == nil && right == nil, nil
+	}
+	if isExprObject(left) && isExprObject(right) {
+		return false, errors.New("comparing objects is not supported")
+	}
+	if b, ok := left.(bool); ok {
+		left = exprNumber(b)
+	}
+	if b, ok := right.(bool); ok {
+		right = exprNumber(b)
+	}
+	left, right = toPrimitive(left), toPrimitive(right)
+	_, leftIsString := left.(string)
+	_, rightIsString := right.(string)
+	if leftIsString && rightIsString {
+		return left == right, nil
+	}
+	if !leftIsString && !rightIsString {
+		return valueEqual(left, right), nil
+	}
+	return exprNumber(left) == exprNumber(right), nil
+}
+
+// 与 JavaScript 的 < > 相同：都是字符串时按字符串比较，否则转换为数字比较，有 NaN 时返回 false
+func jsCompare(left, right interface{}) (int, bool) {
+	left, right = toPrimitive(left), toPrimitive(right)
+	l, leftIsString := left.(string)
+	r, rightIsString := right.(string)
+	if leftIsString && rightIsString {
+		return strings.Compare(l, r), true
+	}
+	if _, ok := toInt64(left); ok {
+		if cmp, ok := compareValues(left, right); ok {
+			return cmp, true
+		}
+	}
+	ln, rn := exprNumber(left), exprNumber(right)
+	switch {
+	case math.IsNaN(ln) || math.IsNaN(rn):
+		return 0, false
+	case ln < rn:
+		return -1, true
+	case ln > rn:
+		return 1, true
+	}
+	return 0, true
+}
+
+// 整数结果返回 int64，与 goja 导出的类型一致
+func numberResult(n float64) interface{} {
+	if n == math.Trunc(n) && math.Abs(n) <= 1<<53 {
+		return int64(n)
+	}
+	return n
+}
+
+// 与 JavaScript 的 String() 相同：数组的元素以 , 连接（null 为空），对象为 [object Object]
+func exprString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return jsNumberString(v)
+	case float32:
+		return jsNumberString(float64(v))
+	}
+	if _, ok := toInt64(value); ok {
+		return templateString(value)
+	}
+	if n, ok := toFloat(value); ok {
+		return jsNumberString(n)
+	}
+	if list, ok := elementsOf(value); ok {
+		items := make([]string, len(list))
+		for i, item := range list {
+			if item != nil {
+				items[i] = exprString(item)
+			}
+		}
+		return strings.Join(items, ",")
+	}
+	return "[object Object]"
+}
+
+// 数字按 JavaScript 的格式输出，绝对值不小于1e21或小于1e-6时使用指数形式
+func jsNumberString(n float64) string {
+	switch {
+	case math.IsNaN(n):
+		return "NaN"
+	case math.IsInf(n, 1):
+		return "Infinity"
+	case math.IsInf(n, -1):
+		return "-Infinity"
+	case n == 0:
+		return "0"
+	}
+	if abs := math.Abs(n); abs >= 1e21 || abs < 1e-6 {
+		// Go 输出 1e+21、1.5e-07，JavaScript 为 1e+21、1.5e-7
+		s := strconv.FormatFloat(n, 'e', -1, 64)
+		i := strings.IndexByte(s, 'e')
+		exponent := strings.TrimLeft(s[i+2:], "0")
+		return s[:i+2] + exponent
+	}
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func exprLength(value interface{}) (int, bool) {
+	if s, ok := value.(string); ok {
+		return utf8.RuneCountInString(s), true
+	}
+	if list, ok := elementsOf(value); ok {
+		return len(list), true
+	}
+	if value != nil {
+		if _, ok := toFloat(value); !ok {
+			if _, ok := value.(bool); !ok {
+				return len(objectKeys(value)), true
+			}
+		}
+	}
+	return 0, false
+}
+
+// ==== 函数 ====
+
+type exprFunc func(args []interface{}) (interface{}, error)
+
+// exprFunctions 表达式中可以调用的函数白名单，参数数量为 -1 时不限
+var exprFunctions = map[string]struct {
+	argc int
+	fn   exprFunc
+}{
+	"len": {1, func(args []interface{}) (interface{}, error) {
+		n, ok := exprLength(args[0])
+		if !ok {
+			return nil, fmt.Errorf("unsupported type %T", args[0])
+		}
+		return int64(n), nil
+	}},
+	"sum": {1, func(args []interface{}) (interface{}, error) {
+		return reduceNumbers(args[0], func(acc, n float64) float64 { return acc + n })
+	}},
+	"min": {-1, func(args []interface{}) (interface{}, error) {
+		return reduceNumbers(numberArgs(args), math.Min)
+	}},
+	"max": {-1, func(args []interface{}) (interface{}, error) {
+		return reduceNumbers(numberArgs(args), math.Max)
+	}},
+	"avg": {1, func(args []interface{}) (interface{}, error) {
+		list, _ := elementsOf(args[0])
+		if len(list) == 0 {
+			return nil, nil
+		}
+		total, err := reduceNumbers(list, func(acc, n float64) float64 { return acc + n })
+		if err != nil {
+			return nil, err
+		}
+		n, _ := toFloat(total)
+		return numberResult(n / float64(len(list))), nil
+	}},
+	"abs":   {1, mathFunc(math.Abs)},
+	"round": {1, mathFunc(math.Round)},
+	"floor": {1, mathFunc(math.Floor)},
+	"ceil":  {1, mathFunc(math.Ceil)},
+	"contains": {2, func(args []interface{}) (interface{}, error) {
+		switch v := args[0].(type) {
+		case nil:
+			return false, nil
+		case string:
+			return strings.Contains(v, exprString(args[1])), nil
+		}
+		if list, ok := elementsOf(args[0]); ok {
+			for _, item := range list {
+				if valueEqual(item, args[1]) {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+		_, ok := childByName(args[0], exprString(args[1]))
+		return ok, nil
+	}},
+	"lower": {1, func(args []interface{}) (interface{}, error) {
+		return strings.ToLower(exprString(args[0])), nil
+	}},
+	"upper": {1, func(args []interface{}) (interface{}, error) {
+		return strings.ToUpper(exprString(args[0])), nil
+	}},
+	"trim": {1, func(args []interface{}) (interface{}, error) {
+		return strings.TrimSpace(exprString(args[0])), nil
+	}},
+	"join": {2, func(args []interface{}) (interface{}, error) {
+		list, _ := elementsOf(args[0])
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = exprString(item)
+		}
+		return strings.Join(items, exprString(args[1])), nil
+	}},
+	"keys": {1, func(args []interface{}) (interface{}, error) {
+		keys := objectKeys(args[0])
+		sort.Strings(keys)
+		result := make([]interface{}, len(keys))
+		for i, key := range keys {
+			result[i] = key
+		}
+		return result, nil
+	}},
+}
+
+func mathFunc(fn func(float64) float64) exprFunc {
+	return func(args []interface{}) (interface{}, error) {
+		return numberResult(fn(exprNumber(args[0]))), nil
+	}
+}
+
+// min/max 可以传一个数组或多个数字
+func numberArgs(args []interface{}) interface{} {
+	if len(args) == 1 {
+		if list, ok := elementsOf(args[0]); ok {
+			return list
+		}
+	}
+	return args
+}
+
+func reduceNumbers(value interface{}, fn func(acc, n float64) float64) (interface{}, error) {
+	list, ok := elementsOf(value)
+	if !ok {
+		return nil, fmt.Errorf("expected array, got %T", value)
+	}
+	if len(list) == 0 {
+		return int64(0), nil
+	}
+	var acc float64
+	for i, item := range list {
+		n := exprNumber(item)
+		if i == 0 {
+			acc = n
+			continue
+		}
+		acc = fn(acc, n)
+	}
+	return numberResult(acc), nil
+}
+
+// ==== 解析 ====
+
+type exprParser struct {
+	src string
+	pos int
+}
+
+func (ep *exprParser) errorf(format string, args ...interface{}) error {
+	return errors.New("invalid expression " + strconv.Quote(ep.src) + " at " + strconv.Itoa(ep.pos) + ": " + fmt.Sprintf(format, args...))
+}
+
+func (ep *exprParser) skipSpaces() {
+	for ep.pos < len(ep.src) && strings.IndexByte(" \t\r\n", ep.src[ep.pos]) >= 0 {
+		ep.pos++
+	}
+}
+
+func (ep *exprParser) peek() byte {
+	ep.skipSpaces()
+	if ep.pos < len(ep.src) {
+		return ep.src[ep.pos]
+	}
+	return 0
+}
+
+func (ep *exprParser) consume(s string) bool {
+	ep.skipSpaces()
+	if strings.HasPrefix(ep.src[ep.pos:], s) {
+		ep.pos += len(s)
+		return true
+	}
+	return false
+}
+
+// 匹配运算符，较长的运算符优先，如 <= 优先于 <
+func (ep *exprParser) consumeOp(ops ...string) string {
+	ep.skipSpaces()
+	for _, op := range ops {
+		if !strings.HasPrefix(ep.src[ep.pos:], op) {
+			continue
+		}
+		// 避免把 == 识别为 =、把 && 的一部分识别为其他运算符
+		next := ep.pos + len(op)
+		if (op == "<" || op == ">" || op == "!") && next < len(ep.src) && ep.src[next] == '=' {
+			continue
+		}
+		ep.pos = next
+		return op
+	}
+	return ""
+}
+
+func (ep *exprParser) parse() (exprNode, error) {
+	if strings.TrimSpace(ep.src) == "" {
+		return nil, ep.errorf("empty expression")
+	}
+	node, err := ep.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if ep.consume(";") {
+		ep.skipSpaces()
+	}
+	if ep.peek() != 0 {
+		return nil, ep.errorf("unexpected %q", ep.src[ep.pos:])
+	}
+	return node, nil
+}
+
+func (ep *exprParser) parseTernary() (exprNode, error) {
+	cond, err := ep.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if !ep.consume("?") {
+		return cond, nil
+	}
+	then, err := ep.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if !ep.consume(":") {
+		return nil, ep.errorf("expected :")
+	}
+	otherwise, err := ep.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	return exprTernary{cond: cond, then: then, otherwise: otherwise}, nil
+}
+
+// 二元运算符按优先级从低到高
+var exprPrecedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"===", "!==", "==", "!="},
+	{"<=", ">=", "<", ">"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (ep *exprParser) parseBinary(level int) (exprNode, error) {
+	if level >= len(exprPrecedence) {
+		return ep.parseUnary()
+	}
+	left, err := ep.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := ep.consumeOp(exprPrecedence[level]...)
+		if op == "" {
+			return left, nil
+		}
+		right, err := ep.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = exprBinary{op: op, left: left, right: right}
+	}
+}
+
+func (ep *exprParser) parseUnary() (exprNode, error) {
+	if op := ep.consumeOp("!", "-", "+"); op != "" {
+		operand, err := ep.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return exprUnary{op: op, operand: operand}, nil
+	}
+	return ep.parsePostfix()
+}
+
+func (ep *exprParser) parsePostfix() (exprNode, error) {
+	node, err := ep.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		optional := ep.consume("?.")
+		switch {
+		case optional, ep.consume("."):
+			name := ep.parseIdent()
+			if name == "" {
+				return nil, ep.errorf("expected property name")
+			}
+			if ep.peek() == '(' {
+				return nil, ep.errorf("method call %s() is not allowed", name)
+			}
+			node = exprMember{object: node, key: exprLiteral{value: name}, optional: optional}
+		case ep.consume("["):
+			key, err := ep.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+			if !ep.consume("]") {
+				return nil, ep.errorf("expected ]")
+			}
+			node = exprMember{object: node, key: key}
+		default:
+			return node, nil
+		}
+	}
+}
+
+func (ep *exprParser) parseIdent() string {
+	ep.skipSpaces()
+	start := ep.pos
+	for ep.pos < len(ep.src) {
+		c := ep.src[ep.pos]
+		if c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (ep.pos > start && c >= '0' && c <= '9') {
+			ep.pos++
+			continue
+		}
+		break
+	}
+	return ep.src[start:ep.pos]
+}
+
+func (ep *exprParser) parsePrimary() (exprNode, error) {
+	c := ep.peek()
+	switch {
+	case c == 0:
+		return nil, ep.errorf("unexpected end of expression")
+	case c == '(':
+		ep.pos++
+		node, err := ep.parseTernary()
+		if err != nil {
+			return nil, err
+		}
+		if !ep.consume(")") {
+			return nil, ep.errorf("expected )")
+		}
+		return node, nil
+	case c == '\'' || c == '"':
+		s, err := ep.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return exprLiteral{value: s}, nil
+	case c >= '0' && c <= '9' || c == '.':
+		return ep.parseNumber()
+	case c == '[':
+		return ep.parseArray()
+	case c == '{':
+		return ep.parseObject()
+	}
+
+	start := ep.pos
+	name := ep.parseIdent()
+	switch {
+	case name == "":
+		return nil, ep.errorf("unexpected %q", string(c))
+	case strings.HasPrefix(name, "$") && len(name) > 1:
+		return exprVariable{name: name[1:]}, nil
+	}
+	switch name {
+	case "true":
+		return exprLiteral{value: true}, nil
+	case "false":
+		return exprLiteral{value: false}, nil
+	case "null", "undefined":
+		return exprLiteral{value: nil}, nil
+	}
+
+	function, ok := exprFunctions[name]
+	if !ok || ep.peek() != '(' {
+		ep.pos = start
+		return nil, ep.errorf("unknown identifier %s", name)
+	}
+	ep.pos++
+	var args []exprNode
+	for !ep.consume(")") {
+		if len(args) > 0 && !ep.consume(",") {
+			return nil, ep.errorf("expected , or )")
+		}
+		arg, err := ep.parseTernary()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	if function.argc >= 0 && len(args) != function.argc {
+		return nil, ep.errorf("%s() expects %d arguments, got %d", name, function.argc, len(args))
+	}
+	if function.argc < 0 && len(args) == 0 {
+		return nil, ep.errorf("%s() expects at least 1 argument", name)
+	}
+	return exprCall{name: name, fn: function.fn, args: args}, nil
+}
+
+func (ep *exprParser) parseString() (string, error) {
+	quote := ep.src[ep.pos]
+	ep.pos++
+	var builder strings.Builder
+	for ep.pos < len(ep.src) {
+		c := ep.src[ep.pos]
+		ep.pos++
+		switch c {
+		case quote:
+			return builder.String(), nil
+		case '\\':
+			if ep.pos >= len(ep.src) {
+				return "", ep.errorf("unterminated string")
+			}
+			escaped := ep.src[ep.pos]
+			ep.pos++
+			switch escaped {
+			case 'n':
+				builder.WriteByte('\n')
+			case 't':
+				builder.WriteByte('\t')
+			case 'r':
+				builder.WriteByte('\r')
+			case 'u':
+				if ep.pos+4 > len(ep.src) {
+					return "", ep.errorf("invalid unicode escape")
+				}
+				code, err := strconv.ParseUint(ep.src[ep.pos:ep.pos+4], 16, 32)
+				if err != nil {
+					return "", ep.errorf("invalid unicode escape")
+				}
+				builder.WriteRune(rune(code))
+				ep.pos += 4
+			default:
+				builder.WriteByte(escaped)
+			}
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return "", ep.errorf("unterminated string")
+}
+
+func (ep *exprParser) parseNumber() (exprNode, error) {
+	start := ep.pos
+	for ep.pos < len(ep.src) {
+		c := ep.src[ep.pos]
+		if (c >= '0' && c <= '9') || c == '.' || c == 'e' || c == 'E' ||
+			((c == '+' || c == '-') && ep.pos > start && (ep.src[ep.pos-1] == 'e' || ep.src[ep.pos-1] == 'E')) {
+			ep.pos++
+			continue
+		}
+		break
+	}
+	n, err := strconv.ParseFloat(ep.src[start:ep.pos], 64)
+	if err != nil {
+		ep.pos = start
+		return nil, ep.errorf("invalid number")
+	}
+	return exprLiteral{value: numberResult(n)}, nil
+}
+
+func (ep *exprParser) parseArray() (exprNode, error) {
+	ep.pos++
+	var items []exprNode
+	for !ep.consume("]") {
+		if len(items) > 0 && !ep.consume(",") {
+			return nil, ep.errorf("expected , or ]")
+		}
+		// 允许末尾的逗号
+		if ep.consume("]") {
+			break
+		}
+		item, err := ep.parseTernary()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return exprArray{items: items}, nil
+}
+
+func (ep *exprParser) parseObject() (exprNode, error) {
+	ep.pos++
+	object := exprObject{}
+	for !ep.consume("}") {
+		if len(object.keys) > 0 && !ep.consume(",") {
+			return nil, ep.errorf("expected , or }")
+		}
+		if ep.consume("}") {
+			break
+		}
+		var key string
+		if c := ep.peek(); c == '\'' || c == '"' {
+			s, err := ep.parseString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		} else if key = ep.parseIdent(); key == "" {
+			return nil, ep.errorf("expected property name")
+		}
+		if !ep.consume(":") {
+			return nil, ep.errorf("expected :")
+		}
+		value, err := ep.parseTernary()
+		if err != nil {
+			return nil, err
+		}
+		object.keys = append(object.keys, key)
+		object.values = append(object.values, value)
+	}
+	return object, nil
+}
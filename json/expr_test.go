@@ -0,0 +1,65 @@
+package json
+
+import (
+	"math"
+	"testing"
+)
+
+func TestToJSON(t *testing.T) {
+	data := map[string]interface{}{
+		"a": 6.0, "zero": 0.0, "list": []interface{}{1.0, 2.0, 3.0},
+		"x": 1.0, "s": "1", "o": map[string]interface{}{"a": []interface{}{1.0}}, "n": nil,
+	}
+	tests := []struct {
+		name string
+		expr string
+		want interface{}
+	}{
+		{"subset", "$a / 2", int64(3)},
+		{"divide by zero", "$a / $zero", math.Inf(1)},
+		{"negative divide by zero", "-$a / 0", math.Inf(-1)},
+		{"infinity string", `"" + $a / 0`, "Infinity"},
+		{"zero by zero is falsy", "$zero / 0 ? 1 : 2", int64(2)},
+		{"loose equality", "$x == $s", true},
+		{"strict equality", "$x === $s", false},
+		{"null equals only null", "$n == 0", false},
+		{"compare number with string", "$o.a[0] > '0'", true},
+		{"compare strings", "'10' < '9'", true},
+		{"array concatenation", "[1,2] + ''", "1,2"},
+		{"object concatenation", `$o + ""`, "[object Object]"},
+		{"number string", `"" + 1e21 + " " + 0.0000001`, "1e+21 1e-7"},
+		{"optional chaining", "$n?.a", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSON.ToJSON(tt.expr, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("ToJSON(%s) = %#v, want %#v", tt.expr, got, tt.want)
+			}
+		})
+	}
+
+	for _, expr := range []string{"$zero % 0", "'a' * 2", "$o * 1"} {
+		got, err := JSON.ToJSON(expr, data)
+		if f, ok := got.(float64); err != nil || !ok || !math.IsNaN(f) {
+			t.Fatalf("ToJSON(%s) = %#v, %v, want NaN", expr, got, err)
+		}
+	}
+
+	for _, expr := range []string{"$missing", "$n.a", "$o == $o"} {
+		if got, err := JSON.ToJSON(expr, data); err == nil {
+			t.Fatalf("ToJSON(%s) = %#v, want error", expr, got)
+		}
+	}
+
+	if _, err := JSON.ToJSON("$list.map(function (v) { return v; })", data); err == nil {
+		t.Fatal("expression outside the subset ran without ScriptFallback")
+	}
+	fallback := &jsonStruct{ScriptFallback: true}
+	if got, err := fallback.ToJSON("$list.map(function (v) { return v * 2; }).length", data); err != nil || got != int64(3) {
+		t.Fatalf("ToJSON with ScriptFallback = %#v, %v, want 3", got, err)
+	}
+}
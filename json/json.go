@@ -1,13 +1,18 @@
 package json
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/dop251/goja"
 	"github.com/google/uuid"
@@ -17,7 +22,9 @@ import (
 // JSON 对应的结构体
 var JSON = &jsonStruct{}
 
-type jsonStruct struct{}
+type jsonStruct struct {
+	ScriptFallback bool // ToJSON 的表达式超出 Evaluate 支持的范围时，是否使用 goja 执行，默认false；开启后配置中的字符串可以执行任意脚本
+}
 
 // 重新对Params根据插件数据结构赋值
 func convertValue(value interface{}) interface{} {
@@ -92,127 +99,365 @@ func fillMap(key string, value interface{}) interface{} {
 }
 
 // ParseParams函数根据插件数据结构解析并赋值给plugParams参数，并赋值默认值
+// 成功时返回 plugParams，actionParams 为 nil 时返回 nil，失败时返回 error；规则见 ParseParamsE
 func (p *jsonStruct) ParseParams(
 	plugParams any,
 	actionParams, defaultParams map[string]interface{}) interface{} {
 	if actionParams == nil {
 		return nil
 	}
-	// Convert actionParams to map[string]interface{}
-	convertedParams := convertValue(actionParams).(map[string]interface{})
+	if err := p.ParseParamsE(plugParams, actionParams, defaultParams); err != nil {
+		return err
+	}
+	return plugParams
+}
 
-	var mutex sync.Mutex
-	mutex.Lock()
-	defer mutex.Unlock()
+// ParseParamsE 与 ParseParams 相同，但只返回 error，actionParams 为 nil 时按空参数处理并填充默认值
+// 字段名取 json 标签，兼容的类型会自动转换（如 "5"→5、5.0→5），转换失败时返回 error
+// 参数未传入、为 null 或空字符串时（见 isMissingValue，与 ParseParamsWithSchema 相同）依次使用 defaultParams 中的值和 default:"..." 标签，
+// 显式传入的 0、false 保持不变；嵌套的结构体和结构体数组递归处理
+func (p *jsonStruct) ParseParamsE(
+	plugParams any,
+	actionParams, defaultParams map[string]interface{}) error {
+	dataValue := reflect.ValueOf(plugParams)
+	if dataValue.Kind() != reflect.Ptr || dataValue.IsNil() {
+		return errors.New("plugParams must be a non-nil pointer")
+	}
+
+	// Convert actionParams to map[string]interface{}
+	convertedParams := map[string]interface{}{}
+	if actionParams != nil {
+		convertedParams = convertValue(actionParams).(map[string]interface{})
+	}
 
-	// 使用Marshal和Unmarshal处理类型转换
-	b, err := json.Marshal(convertedParams)
+	// 先按结构体类型转换参数，再使用Marshal和Unmarshal赋值
+	coerced, err := coerceValue(dataValue.Type().Elem(), convertedParams, "")
 	if err != nil {
 		return err
 	}
+	b, err := json.Marshal(coerced)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(b, plugParams); err != nil {
+		return err
+	}
 
-	// 将JSON数据解析到plugParams中
-	json.Unmarshal(b, plugParams)
+	// 使用反射循环遍历plugParams的每个参数，如果未传入则使用默认值进行填充
+	if dataType := dataValue.Elem(); dataType.Kind() == reflect.Struct {
+		return handleStruct(dataType, convertedParams, defaultParams, "")
+	}
+	return nil
+}
 
-	if defaultParams != nil {
-		// 使用反射循环遍历plugParams的每个参数，如果为空则使用defaultParams进行填充
-		dataType := reflect.ValueOf(plugParams).Elem()
+// 参数为 null 或空字符串时与未传入相同，ParseParamsE 与 ParseParamsWithSchema 都按此规则使用默认值
+func isMissingValue(value interface{}) bool {
+	return value == nil || value == ""
+}
 
-		for i := 0; i < dataType.NumField(); i++ {
-			fieldName := dataType.Type().Field(i).Name
-			handleField(dataType.Field(i), fieldName, defaultParams)
+// 遍历结构体字段填充默认值，given 为传入的参数，用于判断参数是否存在
+func handleStruct(structValue reflect.Value, given, defaultParams map[string]interface{}, path string) error {
+	structType := structValue.Type()
+	for i := 0; i < structValue.NumField(); i++ {
+		structField := structType.Field(i)
+		// 未指定名称的嵌入结构体，字段与外层平级
+		if structField.Anonymous && structField.Type.Kind() == reflect.Struct && structField.Tag.Get("json") == "" {
+			if err := handleStruct(structValue.Field(i), given, defaultParams, path); err != nil {
+				return err
+			}
+			continue
+		}
+		fieldName, ok := jsonFieldName(structField)
+		if !ok {
+			continue
+		}
+		if err := handleField(structValue.Field(i), structField, fieldName, given, defaultParams, path); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	return plugParams
+// 参数未传入且字段为空时填充默认值，嵌套的结构体递归处理
+func handleField(field reflect.Value, structField reflect.StructField, fieldName string,
+	given, defaultParams map[string]interface{}, path string) error {
+	fieldPath := joinFieldPath(path, fieldName)
+	givenValue := given[fieldName]
+
+	if isMissingValue(givenValue) && isEmptyField(field) {
+		defaultValue := defaultParams[fieldName]
+		if isMissingValue(defaultValue) {
+			if tag, ok := structField.Tag.Lookup("default"); ok {
+				defaultValue = tag
+			}
+		}
+		if !isMissingValue(defaultValue) {
+			if err := setFieldValue(field, defaultValue, fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 嵌套的结构体和结构体数组
+	nestedGiven, _ := givenValue.(map[string]interface{})
+	nestedDefaults, _ := defaultParams[fieldName].(map[string]interface{})
+	target := field
+	if target.Kind() == reflect.Ptr {
+		if target.IsNil() {
+			return nil
+		}
+		target = target.Elem()
+	}
+	switch {
+	case target.Kind() == reflect.Struct && target.Type() != reflect.TypeOf(time.Time{}):
+		return handleStruct(target, nestedGiven, nestedDefaults, fieldPath)
+	case target.Kind() == reflect.Slice || target.Kind() == reflect.Array:
+		givenList, _ := givenValue.([]interface{})
+		for i := 0; i < target.Len(); i++ {
+			item := target.Index(i)
+			if item.Kind() == reflect.Ptr {
+				if item.IsNil() {
+					continue
+				}
+				item = item.Elem()
+			}
+			if item.Kind() != reflect.Struct || item.Type() == reflect.TypeOf(time.Time{}) {
+				break
+			}
+			var itemGiven map[string]interface{}
+			if i < len(givenList) {
+				itemGiven, _ = givenList[i].(map[string]interface{})
+			}
+			if err := handleStruct(item, itemGiven, nil, fieldPath+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
-// 判断字段类型并进行处理
-func handleField(field reflect.Value, fieldName string, defaultParams map[string]interface{}) {
-	var mutex sync.Mutex
-	mutex.Lock()
-	defer mutex.Unlock()
-	switch field.Interface().(type) {
-	case string, map[string]interface{}, []interface{}:
-		// Map字段
-		if field.Len() == 0 {
-			// 空数据处理逻辑
-			if value, ok := defaultParams[fieldName]; ok {
-				field.Set(reflect.ValueOf(value))
+// 字符串、数组、Map长度为0，或其他类型为零值时视为空
+func isEmptyField(field reflect.Value) bool {
+	switch field.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		return field.Len() == 0
+	}
+	return field.IsZero()
+}
+
+// 将值转换为字段的类型后赋值
+func setFieldValue(field reflect.Value, value interface{}, path string) error {
+	coerced, err := coerceValue(field.Type(), convertValue(value), path)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(coerced)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	newValue := reflect.New(field.Type())
+	if err = json.Unmarshal(b, newValue.Interface()); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	field.Set(newValue.Elem())
+	return nil
+}
+
+// 按目标类型转换参数值：字符串转数字/布尔，整数值的浮点数转整数，数字/布尔转字符串
+// 结构体、数组和Map递归转换，无法转换时返回带路径的错误
+func coerceValue(t reflect.Type, value interface{}, path string) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	fail := func() (interface{}, error) {
+		name := path
+		if name == "" {
+			name = "params"
+		}
+		return nil, fmt.Errorf("%s: cannot convert %v (%T) to %s", name, value, value, t.String())
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n float64
+		switch v := value.(type) {
+		case json.Number:
+			// 大整数直接解析，避免经过 float64 丢失精度
+			if t.Kind() < reflect.Uint {
+				if i, err := v.Int64(); err == nil {
+					if reflect.Zero(t).OverflowInt(i) {
+						return fail()
+					}
+					return i, nil
+				}
+			} else if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+				if reflect.Zero(t).OverflowUint(u) {
+					return fail()
+				}
+				return u, nil
+			}
+			f, err := v.Float64()
+			if err != nil {
+				return fail()
 			}
+			n = f
+		case string:
+			return coerceValue(t, json.Number(strings.TrimSpace(v)), path)
+		case bool:
+			return fail()
+		default:
+			f, ok := toFloat(v)
+			if !ok {
+				return value, nil
+			}
+			n = f
+		}
+		if n != math.Trunc(n) {
+			return fail()
 		}
-	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
-		// 数字字段
-		if field.Interface() == 0 {
-			// 0 值处理逻辑
-			if value, ok := defaultParams[fieldName]; ok {
-				field.Set(reflect.ValueOf(value))
+		if t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64 {
+			if n < 0 || reflect.Zero(t).OverflowUint(uint64(n)) {
+				return fail()
 			}
+			return uint64(n), nil
 		}
-	default:
-		// 其他类型字段的处理逻辑
+		if reflect.Zero(t).OverflowInt(int64(n)) {
+			return fail()
+		}
+		return int64(n), nil
+	case reflect.Float32, reflect.Float64:
+		if v, ok := value.(string); ok {
+			n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return fail()
+			}
+			return n, nil
+		}
+		if _, ok := value.(bool); ok {
+			return fail()
+		}
+	case reflect.Bool:
+		if v, ok := value.(string); ok {
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return fail()
+			}
+			return b, nil
+		}
+		if _, ok := value.(bool); !ok {
+			return fail()
+		}
+	case reflect.String:
+		switch v := value.(type) {
+		case json.Number:
+			return v.String(), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		case map[string]interface{}, []interface{}:
+			return fail()
+		default:
+			if n, ok := toFloat(v); ok {
+				return strconv.FormatFloat(n, 'f', -1, 64), nil
+			}
+		}
+	case reflect.Struct:
+		m, ok := value.(map[string]interface{})
+		if !ok || t == reflect.TypeOf(time.Time{}) {
+			return value, nil
+		}
+		result := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			result[k] = v
+		}
+		if err := coerceStructFields(t, result, path); err != nil {
+			return nil, err
+		}
+		return result, nil
+	case reflect.Slice, reflect.Array:
+		list, ok := value.([]interface{})
+		if !ok {
+			return value, nil
+		}
+		result := make([]interface{}, len(list))
+		for i, item := range list {
+			coerced, err := coerceValue(t.Elem(), item, path+"["+strconv.Itoa(i)+"]")
+			if err != nil {
+				return nil, err
+			}
+			result[i] = coerced
+		}
+		return result, nil
+	case reflect.Map:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return value, nil
+		}
+		result := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			coerced, err := coerceValue(t.Elem(), v, joinFieldPath(path, k))
+			if err != nil {
+				return nil, err
+			}
+			result[k] = coerced
+		}
+		return result, nil
+	}
+	return value, nil
+}
+
+// 按 json 标签转换结构体对应的参数，嵌入的结构体字段与外层平级
+func coerceStructFields(t reflect.Type, m map[string]interface{}, path string) error {
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		if structField.Anonymous && structField.Type.Kind() == reflect.Struct && structField.Tag.Get("json") == "" {
+			if err := coerceStructFields(structField.Type, m, path); err != nil {
+				return err
+			}
+			continue
+		}
+		fieldName, ok := jsonFieldName(structField)
+		if !ok {
+			continue
+		}
+		value, exists := m[fieldName]
+		if !exists {
+			continue
+		}
+		coerced, err := coerceValue(structField.Type, value, joinFieldPath(path, fieldName))
+		if err != nil {
+			return err
+		}
+		m[fieldName] = coerced
 	}
+	return nil
+}
+
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
 }
 
 var XReplaceMutex = &sync.RWMutex{}
 
 // 根据data对象替换dataStr内的路径
-// mustHas 必须有值，否则报错
+// mustHas 必须有值，缺少变量时返回 false；需要过滤器、转义或具体的错误信息时使用 Render
 func (p *jsonStruct) ReplaceXPathValue(
 	data interface{},
 	dataStr string,
 	mustHas bool) (string, bool) {
 	XReplaceMutex.RLock()
 	defer XReplaceMutex.RUnlock()
-	dataStr = strings.TrimSpace(dataStr)
-	resultStr := dataStr
-	// 替换Key包含${}结构
-	pattern := `\$([A-Z$]{1}[A-Za-z0-9_]*)(\[\"){0,2}([\p{Han}A-Za-z0-9._-]*)(\"\]){0,2}([.A-Za-z0-9_^]*)`
-	reg := regexp.MustCompile(pattern)
-	matches := reg.FindAllString(resultStr, -1)
-
-	for _, match := range matches {
-		matchStr := match[1:] // 去掉$符号，得到变量名
-		// if p.HasXPath(matchStr) {
-		// 	matchStr, _ = p.ReplaceXPathValue(data, matchStr, mustHas)
-		// 	resultStr = strings.Replace(resultStr, match, "${"+matchStr+"}", -1)
-		// }
-
-		xpathValue, exists := p.GetXPathValue(data, matchStr)
-		if exists {
-			replacement := ""
-			switch v := xpathValue.(type) {
-			case map[string]interface{}, []interface{}:
-				// 如果是对象或数组，将其转换为 JSON 字符串格式
-				jsonValue, err := json.Marshal(v)
-				if err != nil {
-					// 处理错误，这里可以根据实际情况进行处理
-					replacement = fmt.Sprint(xpathValue)
-				} else {
-					replacement = string(jsonValue)
-				}
-			case float64, int, int32, int64:
-				// 如果是数字，转换为字符串
-				replacement = fmt.Sprint(v)
-			case nil:
-				replacement = "null"
-			default:
-				// 如果是字符串，直接使用
-				replacement = xpathValue.(string)
-				// 处理其他类型，如 null 或 undefined，不进行替换
-			}
-
-			// 进行替换
-			resultStr = strings.Replace(resultStr, "$"+matchStr, replacement, -1)
-		} else if mustHas {
-			// 必须有值，则报错
-			return "", false
-		} else {
-			// 进行替换
-			resultStr = strings.Replace(resultStr, "$"+matchStr, "", -1)
-		}
+	// 只替换 $Var.path，不转义；${...} 原样保留，不会改写 JS 模板字符串
+	resultStr, err := render(strings.TrimSpace(dataStr), data, EscapeNone, mustHas, false)
+	if err != nil {
+		return "", false
 	}
-
 	return resultStr, true
 }
 
@@ -240,36 +485,265 @@ func (p *jsonStruct) GetXPathValue(data interface{}, xpath string) (interface{},
 
 var XSetMutex = &sync.RWMutex{}
 
+// 根据XPath设置data的指定路径的数据，支持 a.b[2].c 下标与 a.list[] 追加
+// 中间节点不存在时自动创建，类型不兼容或下标超出数组长度时忽略，需要报错时使用 SetXPathValueStrict
+// 下标最大为数组长度（等同追加），不会在中间填充空元素
 func (p *jsonStruct) SetXPathValue(currentMap interface{}, xpath string, data interface{}) {
+	_ = setXPathValue(currentMap, xpath, data, false)
+}
+
+// 根据XPath设置数据，中间节点类型不兼容（如在字符串上取下标）时返回错误，不覆盖也不忽略
+// currentMap 为数组时不能改变其长度
+func (p *jsonStruct) SetXPathValueStrict(currentMap interface{}, xpath string, data interface{}) error {
+	return setXPathValue(currentMap, xpath, data, true)
+}
+
+func setXPathValue(currentMap interface{}, xpath string, data interface{}, strict bool) error {
 	XSetMutex.Lock()
 	defer XSetMutex.Unlock()
-	names := strings.Split(xpath, ".")
-	for i, name := range names {
-		if i == len(names)-1 {
-			switch m := currentMap.(type) {
-			case map[string]interface{}:
-				m[name] = data
-			case *sync.Map:
-				m.Store(name, data)
+	segments, err := parseXPathSegments(xpath)
+	if err != nil {
+		return err
+	}
+	result, err := setValueBySegments(currentMap, segments, data, strict)
+	if errors.Is(err, errSetSkipped) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !sameContainer(currentMap, result) && strict {
+		return errors.New("xpath " + xpath + ": root node cannot be replaced")
+	}
+	return nil
+}
+
+// 根据XPath删除数据，返回删除后的根节点与是否删除
+// 删除数组元素时生成新的数组，不修改调用方持有的数组；currentMap 本身为数组时需使用返回的根节点
+func (p *jsonStruct) DeleteXPathValue(currentMap interface{}, xpath string) (interface{}, bool) {
+	XSetMutex.Lock()
+	defer XSetMutex.Unlock()
+	segments, err := parseXPathSegments(xpath)
+	if err != nil || len(segments) == 0 {
+		return currentMap, false
+	}
+	return deleteValueBySegments(currentMap, segments)
+}
+
+// xpathSegment 路径的一段：属性名、下标或追加
+type xpathSegment struct {
+	key    string
+	index  int
+	isKey  bool
+	append bool
+}
+
+func (s xpathSegment) String() string {
+	switch {
+	case s.isKey:
+		return s.key
+	case s.append:
+		return "[]"
+	}
+	return "[" + strconv.Itoa(s.index) + "]"
+}
+
+// 拆分路径，如 a.b[2]["c.d"].list[] 拆分为 a, b, [2], c.d, list, []
+func parseXPathSegments(xpath string) ([]xpathSegment, error) {
+	xpath = strings.TrimSpace(xpath)
+	if strings.HasPrefix(xpath, "${") && strings.HasSuffix(xpath, "}") {
+		xpath = xpath[2 : len(xpath)-1]
+	}
+	var segments []xpathSegment
+	for i := 0; i < len(xpath); {
+		switch xpath[i] {
+		case '.':
+			i++
+		case '[':
+			end := strings.IndexByte(xpath[i:], ']')
+			if end < 0 {
+				return nil, errors.New("invalid xpath " + xpath + ": missing ]")
 			}
-			break
-		} else {
-			switch m := currentMap.(type) {
-			case map[string]interface{}:
-				if m[name] == nil {
-					m[name] = make(map[string]interface{})
+			inner := strings.TrimSpace(xpath[i+1 : i+end])
+			i += end + 1
+			switch {
+			case inner == "":
+				segments = append(segments, xpathSegment{append: true})
+			case strings.HasPrefix(inner, "\"") || strings.HasPrefix(inner, "'"):
+				segments = append(segments, xpathSegment{key: strings.Trim(inner, "\"'"), isKey: true})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, errors.New("invalid xpath " + xpath + ": bad index " + inner)
 				}
-				currentMap = m[name]
-			case *sync.Map:
-				result, _ := m.Load(name)
-				if result == nil {
-					result = &sync.Map{}
-					m.Store(name, result)
+				segments = append(segments, xpathSegment{index: index})
+			}
+		default:
+			end := strings.IndexAny(xpath[i:], ".[")
+			if end < 0 {
+				end = len(xpath) - i
+			}
+			segments = append(segments, xpathSegment{key: xpath[i : i+end], isKey: true})
+			i += end
+		}
+	}
+	return segments, nil
+}
+
+// 非严格模式下忽略设置时返回，各层收到错误后都不写回，避免留下新建的空节点
+var errSetSkipped = errors.New("xpath set skipped")
+
+// 递归设置数据，返回设置后的节点；数组扩容或新建节点时由上一层写回
+func setValueBySegments(node interface{}, segments []xpathSegment, data interface{}, strict bool) (interface{}, error) {
+	if len(segments) == 0 {
+		return data, nil
+	}
+	segment, rest := segments[0], segments[1:]
+	incompatible := func() (interface{}, error) {
+		if strict {
+			return node, fmt.Errorf("xpath %s: cannot set on %T", segment, node)
+		}
+		return node, errSetSkipped
+	}
+
+	switch {
+	case segment.isKey:
+		switch m := node.(type) {
+		case nil:
+			child, err := setValueBySegments(nil, rest, data, strict)
+			if err != nil {
+				return node, err
+			}
+			return map[string]interface{}{segment.key: child}, nil
+		case map[string]interface{}:
+			child, err := setValueBySegments(m[segment.key], rest, data, strict)
+			if err != nil {
+				return node, err
+			}
+			m[segment.key] = child
+			return m, nil
+		case *sync.Map:
+			current, _ := m.Load(segment.key)
+			if current == nil && len(rest) > 0 && rest[0].isKey {
+				current = &sync.Map{}
+			}
+			child, err := setValueBySegments(current, rest, data, strict)
+			if err != nil {
+				return node, err
+			}
+			m.Store(segment.key, child)
+			return m, nil
+		}
+		return incompatible()
+	case segment.append:
+		list, ok := node.([]interface{})
+		if !ok && node != nil {
+			return incompatible()
+		}
+		child, err := setValueBySegments(nil, rest, data, strict)
+		if err != nil {
+			return node, err
+		}
+		return append(list, child), nil
+	default:
+		list, ok := node.([]interface{})
+		if !ok && node != nil {
+			return incompatible()
+		}
+		index := segment.index
+		if index < 0 {
+			index += len(list)
+			if index < 0 {
+				if strict {
+					return node, fmt.Errorf("xpath %s: index out of range", segment)
 				}
-				currentMap = result
+				return node, errSetSkipped
+			}
+		}
+		if index > len(list) {
+			if strict {
+				return node, fmt.Errorf("xpath %s: index out of range", segment)
+			}
+			return node, errSetSkipped
+		}
+		if index == len(list) {
+			child, err := setValueBySegments(nil, rest, data, strict)
+			if err != nil {
+				return node, err
+			}
+			return append(list, child), nil
+		}
+		child, err := setValueBySegments(list[index], rest, data, strict)
+		if err != nil {
+			return node, err
+		}
+		list[index] = child
+		return list, nil
+	}
+}
+
+// 递归删除数据，返回删除后的节点
+func deleteValueBySegments(node interface{}, segments []xpathSegment) (interface{}, bool) {
+	segment, rest := segments[0], segments[1:]
+	if segment.append {
+		return node, false
+	}
+	if segment.isKey {
+		switch m := node.(type) {
+		case map[string]interface{}:
+			child, ok := m[segment.key]
+			if !ok {
+				return node, false
+			}
+			if len(rest) == 0 {
+				delete(m, segment.key)
+				return m, true
+			}
+			newChild, deleted := deleteValueBySegments(child, rest)
+			m[segment.key] = newChild
+			return m, deleted
+		case *sync.Map:
+			child, ok := m.Load(segment.key)
+			if !ok {
+				return node, false
 			}
+			if len(rest) == 0 {
+				m.Delete(segment.key)
+				return m, true
+			}
+			newChild, deleted := deleteValueBySegments(child, rest)
+			m.Store(segment.key, newChild)
+			return m, deleted
 		}
+		return node, false
+	}
+
+	list, ok := node.([]interface{})
+	if !ok {
+		return node, false
 	}
+	index := segment.index
+	if index < 0 {
+		index += len(list)
+	}
+	if index < 0 || index >= len(list) {
+		return node, false
+	}
+	if len(rest) == 0 {
+		return append(append(make([]interface{}, 0, len(list)-1), list[:index]...), list[index+1:]...), true
+	}
+	newChild, deleted := deleteValueBySegments(list[index], rest)
+	list[index] = newChild
+	return list, deleted
+}
+
+// 是否为同一个容器，数组长度改变后不能写回调用方
+func sameContainer(a, b interface{}) bool {
+	if listA, ok := a.([]interface{}); ok {
+		listB, ok := b.([]interface{})
+		return ok && len(listA) == len(listB)
+	}
+	return true
 }
 
 func getValueByKeys(data interface{}, keys []string) (interface{}, bool) {
@@ -397,7 +871,12 @@ func (p *jsonStruct) Stringify(data interface{}) string {
 	}
 	jsonData := data
 	if !underscore.Underscore.IsObject(jsonData) {
-		return data.(string)
+		switch v := data.(type) {
+		case string:
+			return v
+		case json.Number:
+			return v.String()
+		}
 	}
 
 	convertedParams := convertValue(data)
@@ -409,43 +888,79 @@ func (p *jsonStruct) Stringify(data interface{}) string {
 	return string(jsonStr)
 }
 
-func (p *jsonStruct) Parse(data interface{}) (interface{}, bool) {
-	// 检查data是否为[]byte类型
-	if dataBytes, ok := data.([]byte); ok {
-		var result interface{}
-		err := json.Unmarshal(dataBytes, &result)
-		if err != nil {
-			// 处理解析失败的情况
-			return err.Error(), false
-		}
-		return result, true
+// ParseOption Parse 的选项
+type ParseOption func(*parseOptions)
+
+type parseOptions struct {
+	useNumber bool
+}
+
+// 数字解析为 json.Number 而不是 float64，保留64位整数ID和金额的精度
+func UseNumber() ParseOption {
+	return func(o *parseOptions) {
+		o.useNumber = true
 	}
+}
 
-	// 检查data是否为string类型
-	if dataStr, ok := data.(string); ok {
-		var result interface{}
-		err := json.Unmarshal([]byte(dataStr), &result)
-		if err != nil {
+func (p *jsonStruct) Parse(data interface{}, opts ...ParseOption) (interface{}, bool) {
+	options := &parseOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	var dataBytes []byte
+	switch v := data.(type) {
+	case []byte:
+		dataBytes = v
+	case string:
+		dataBytes = []byte(v)
+	default:
+		// 如果data既不是string也不是[]byte类型，返回原始数据和false
+		return data, false
+	}
+
+	var result interface{}
+	if !options.useNumber {
+		if err := json.Unmarshal(dataBytes, &result); err != nil {
 			// 处理解析失败的情况
 			return err.Error(), false
 		}
 		return result, true
 	}
 
-	// 如果data既不是string也不是[]byte类型，返回原始数据和false
-	return data, false
+	decoder := json.NewDecoder(bytes.NewReader(dataBytes))
+	decoder.UseNumber()
+	if err := decoder.Decode(&result); err != nil {
+		return err.Error(), false
+	}
+	// 与 Unmarshal 一致，值之后不能有其他内容
+	if _, err := decoder.Token(); err != io.EOF {
+		return "invalid character after top-level value", false
+	}
+	return result, true
 }
 
+// 深拷贝数据，按结构复制而不经过序列化，数值、结构体等类型保持不变
+// 需要 JSON 语义（数字为 float64、结构体转换为 map）时使用 Parse(Stringify(data))
 func (p *jsonStruct) Clone(data interface{}) interface{} {
 	if data == nil || !underscore.Underscore.IsObject(data) {
 		return data
 	}
 
-	result, _ := p.Parse(p.Stringify(data))
-	return result
+	return cloneValue(data)
 }
 
+// 计算表达式的值，变量为 $key，见 Evaluate
+// 表达式无法编译时，只有 ScriptFallback 为 true 才使用 goja 执行，否则返回编译错误
 func (p *jsonStruct) ToJSON(inputStr string, data map[string]interface{}) (interface{}, error) {
+	node, err := compileExpr(inputStr)
+	if err == nil {
+		return node.eval(data)
+	}
+	if !p.ScriptFallback {
+		return nil, err
+	}
+
 	// 复杂表达式，通过VM获取
 	newVm := goja.New()
 
@@ -534,6 +1049,14 @@ func (p *jsonStruct) limitJSONDepth(data interface{}, depth int, arrayLimit int)
 			}
 		}
 		return resultMap
+	case float64:
+		// 大数不使用科学计数法输出
+		if v == math.Trunc(v) && math.Abs(v) >= 1e21 {
+			return json.Number(strconv.FormatFloat(v, 'f', -1, 64))
+		}
+		return v
+	case json.Number:
+		return v
 	default:
 		return v
 	}
@@ -0,0 +1,158 @@
+package json
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetXPathValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    interface{}
+		xpath   string
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"nested key", map[string]interface{}{}, "a.b", 1, map[string]interface{}{"a": map[string]interface{}{"b": 1}}, false},
+		{"index", map[string]interface{}{"a": []interface{}{1, 2}}, "a[1]", 3, map[string]interface{}{"a": []interface{}{1, 3}}, false},
+		{"negative index", map[string]interface{}{"a": []interface{}{1, 2}}, "a[-1]", 3, map[string]interface{}{"a": []interface{}{1, 3}}, false},
+		{"index at length", map[string]interface{}{"a": []interface{}{1}}, "a[1].b", 2,
+			map[string]interface{}{"a": []interface{}{1, map[string]interface{}{"b": 2}}}, false},
+		{"append", map[string]interface{}{"a": []interface{}{1}}, "a[]", 2, map[string]interface{}{"a": []interface{}{1, 2}}, false},
+		{"index beyond length", map[string]interface{}{"a": []interface{}{1}}, "a[100000000]", 2, map[string]interface{}{"a": []interface{}{1}}, true},
+		{"incompatible", map[string]interface{}{"a": "x"}, "a.b", 1, map[string]interface{}{"a": "x"}, true},
+		{"root array length", []interface{}{1}, "[]", 2, []interface{}{1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := JSON.SetXPathValueStrict(tt.data, tt.xpath, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetXPathValueStrict error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(tt.data, tt.want) {
+				t.Fatalf("data = %#v, want %#v", tt.data, tt.want)
+			}
+		})
+	}
+}
+
+// 非严格模式忽略设置时不修改数据，也不创建中间节点
+func TestSetXPathValueSkipped(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  map[string]interface{}
+		xpath string
+	}{
+		{"index on missing key", map[string]interface{}{}, "a[2]"},
+		{"nested index on missing key", map[string]interface{}{}, "a.b[5].c"},
+		{"index beyond length", map[string]interface{}{"a": []interface{}{}}, "a[3].b"},
+		{"incompatible", map[string]interface{}{"a": map[string]interface{}{"b": "x"}}, "a.b.c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := JSON.Clone(tt.data)
+			JSON.SetXPathValue(tt.data, tt.xpath, 1)
+			if !reflect.DeepEqual(tt.data, want) {
+				t.Fatalf("data = %#v, want %#v", tt.data, want)
+			}
+		})
+	}
+}
+
+func TestDeleteXPathValue(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        interface{}
+		xpath       string
+		want        interface{}
+		wantDeleted bool
+	}{
+		{"key", map[string]interface{}{"a": 1, "b": 2}, "a", map[string]interface{}{"b": 2}, true},
+		{"missing key", map[string]interface{}{"a": 1}, "b", map[string]interface{}{"a": 1}, false},
+		{"nested index", map[string]interface{}{"a": []interface{}{1, 2, 3}}, "a[0]", map[string]interface{}{"a": []interface{}{2, 3}}, true},
+		{"root index", []interface{}{1, 2, 3}, "[0]", []interface{}{2, 3}, true},
+		{"out of range", []interface{}{1}, "[3]", []interface{}{1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, deleted := JSON.DeleteXPathValue(tt.data, tt.xpath)
+			if deleted != tt.wantDeleted {
+				t.Fatalf("deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+			if !reflect.DeepEqual(root, tt.want) {
+				t.Fatalf("root = %#v, want %#v", root, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteXPathValueKeepsCallerSlice(t *testing.T) {
+	list := []interface{}{1, 2, 3}
+	root, deleted := JSON.DeleteXPathValue(list, "[0]")
+	if !deleted || !reflect.DeepEqual(root, []interface{}{2, 3}) {
+		t.Fatalf("root = %v, deleted = %v", root, deleted)
+	}
+	if !reflect.DeepEqual(list, []interface{}{1, 2, 3}) {
+		t.Fatalf("caller slice modified: %v", list)
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	type item struct {
+		Name string `json:"name" default:"item"`
+	}
+	type params struct {
+		Name    string  `json:"name" default:"tag"`
+		Count   int     `json:"count" default:"5"`
+		Ratio   float64 `json:"ratio"`
+		Enabled bool    `json:"enabled" default:"true"`
+		Items   []item  `json:"items"`
+	}
+	tests := []struct {
+		name     string
+		action   map[string]interface{}
+		defaults map[string]interface{}
+		want     params
+		wantErr  bool
+	}{
+		{"tag defaults", map[string]interface{}{}, nil, params{Name: "tag", Count: 5, Enabled: true}, false},
+		{"nil params", nil, map[string]interface{}{"ratio": 0.5}, params{Name: "tag", Count: 5, Ratio: 0.5, Enabled: true}, false},
+		{"null and empty string are missing", map[string]interface{}{"name": "", "count": nil, "enabled": nil},
+			map[string]interface{}{"name": "given"}, params{Name: "given", Count: 5, Enabled: true}, false},
+		{"explicit zero values", map[string]interface{}{"count": 0, "enabled": false, "ratio": 0}, nil,
+			params{Name: "tag", Enabled: false}, false},
+		{"coercion", map[string]interface{}{"count": "7", "ratio": "1.5", "enabled": "false"}, nil,
+			params{Name: "tag", Count: 7, Ratio: 1.5}, false},
+		{"nested defaults", map[string]interface{}{"items": []interface{}{map[string]interface{}{"name": ""}, map[string]interface{}{"name": "b"}}}, nil,
+			params{Name: "tag", Count: 5, Enabled: true, Items: []item{{Name: "item"}, {Name: "b"}}}, false},
+		{"conversion error", map[string]interface{}{"count": "x"}, nil, params{}, true},
+		{"fractional int", map[string]interface{}{"count": 1.5}, nil, params{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got params
+			err := JSON.ParseParamsE(&got, tt.action, tt.defaults)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseParamsE error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("params = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+	if err := JSON.ParseParamsE(params{}, nil, nil); err == nil {
+		t.Fatal("non-pointer params returned no error")
+	}
+
+	var got params
+	if result := JSON.ParseParams(&got, map[string]interface{}{"count": "7"}, nil); result != &got || got.Count != 7 {
+		t.Fatalf("ParseParams = %#v, want the params pointer", result)
+	}
+	if result := JSON.ParseParams(&got, nil, nil); result != nil {
+		t.Fatalf("ParseParams with nil params = %#v, want nil", result)
+	}
+	if _, ok := JSON.ParseParams(&got, map[string]interface{}{"count": "x"}, nil).(error); !ok {
+		t.Fatal("ParseParams conversion failure did not return an error")
+	}
+}
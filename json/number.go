@@ -0,0 +1,93 @@
+package json
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// JS 中可以精确表示的最大整数 2^53-1
+const maxSafeInteger = 1<<53 - 1
+
+// 转换为 JS 可以安全表示的数字：安全范围内的整数为 int64，有效数字不超过15位的小数为 float64，
+// 其他（如64位ID、高精度金额）转换为字符串，避免精度丢失。运行时不支持 BigInt，大整数不会转换为 BigInt
+func (p *jsonStruct) SafeNumber(v interface{}) interface{} {
+	switch n := v.(type) {
+	case uint64:
+		if n <= maxSafeInteger {
+			return int64(n)
+		}
+		return strconv.FormatUint(n, 10)
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			if i >= -maxSafeInteger && i <= maxSafeInteger {
+				return i
+			}
+			return n.String()
+		}
+		if significantDigits(n.String()) <= 15 {
+			if f, err := n.Float64(); err == nil {
+				return f
+			}
+		}
+		return n.String()
+	}
+	return v
+}
+
+// 按 SafeNumber 转换数据中的 json.Number 与 uint64，返回值表示是否发生了转换
+// inPlace 为 true 时直接修改 map 与数组，保持与调用方共享；否则只复制需要转换的 map 或数组
+func (p *jsonStruct) SafeNumbers(v interface{}, inPlace bool) (interface{}, bool) {
+	switch t := v.(type) {
+	case json.Number, uint64:
+		return p.SafeNumber(t), true
+	case map[string]interface{}:
+		result := t
+		changed := false
+		for k, item := range t {
+			converted, ok := p.SafeNumbers(item, inPlace)
+			if !ok {
+				continue
+			}
+			if !changed && !inPlace {
+				result = make(map[string]interface{}, len(t))
+				for k2, item2 := range t {
+					result[k2] = item2
+				}
+			}
+			changed = true
+			result[k] = converted
+		}
+		return result, changed
+	case []interface{}:
+		result := t
+		changed := false
+		for i, item := range t {
+			converted, ok := p.SafeNumbers(item, inPlace)
+			if !ok {
+				continue
+			}
+			if !changed && !inPlace {
+				result = make([]interface{}, len(t))
+				copy(result, t)
+			}
+			changed = true
+			result[i] = converted
+		}
+		return result, changed
+	}
+	return v, false
+}
+
+// 十进制数字的有效位数，忽略符号、小数点、指数和首尾的0
+func significantDigits(s string) int {
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimLeft(s, "+-")
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(s, "0")
+	}
+	s = strings.TrimLeft(strings.Replace(s, ".", "", 1), "0")
+	return len(s)
+}
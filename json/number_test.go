@@ -0,0 +1,65 @@
+package json
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSafeNumber(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want interface{}
+	}{
+		{json.Number("42"), int64(42)},
+		{json.Number("-9007199254740991"), int64(-9007199254740991)},
+		{json.Number("9007199254740993"), "9007199254740993"},
+		{json.Number("9223372036854775807"), "9223372036854775807"},
+		{json.Number("19.99"), 19.99},
+		{json.Number("12345678901234.567891"), "12345678901234.567891"},
+		{json.Number("1e21"), 1e21},
+		{uint64(7), int64(7)},
+		{uint64(1 << 63), "9223372036854775808"},
+		{"text", "text"},
+	}
+	for _, tt := range tests {
+		if got := JSON.SafeNumber(tt.in); got != tt.want {
+			t.Fatalf("SafeNumber(%#v) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSafeNumbers(t *testing.T) {
+	data := map[string]interface{}{"id": json.Number("9007199254740993"), "list": []interface{}{json.Number("1")}, "s": "x"}
+	copied, changed := JSON.SafeNumbers(data, false)
+	want := map[string]interface{}{"id": "9007199254740993", "list": []interface{}{int64(1)}, "s": "x"}
+	if !changed || !reflect.DeepEqual(copied, want) {
+		t.Fatalf("SafeNumbers copy = %#v, %v", copied, changed)
+	}
+	if _, ok := data["id"].(json.Number); !ok {
+		t.Fatalf("copy mode modified the original: %#v", data)
+	}
+
+	inPlace, changed := JSON.SafeNumbers(data, true)
+	if !changed || !reflect.DeepEqual(data, want) || reflect.ValueOf(inPlace).Pointer() != reflect.ValueOf(data).Pointer() {
+		t.Fatalf("SafeNumbers in place = %#v, %v", data, changed)
+	}
+
+	if _, changed = JSON.SafeNumbers(map[string]interface{}{"a": 1.5}, false); changed {
+		t.Fatal("SafeNumbers reported a change without numbers to convert")
+	}
+}
+
+func TestParseUseNumber(t *testing.T) {
+	result, ok := JSON.Parse(`{"id":9223372036854775807,"f":1.5}`, UseNumber())
+	if !ok {
+		t.Fatalf("Parse failed: %v", result)
+	}
+	m := result.(map[string]interface{})
+	if m["id"] != json.Number("9223372036854775807") || m["f"] != json.Number("1.5") {
+		t.Fatalf("Parse = %#v", m)
+	}
+	if _, ok = JSON.Parse(`{"a":1} x`, UseNumber()); ok {
+		t.Fatal("Parse accepted trailing data")
+	}
+}
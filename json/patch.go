@@ -0,0 +1,396 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSON Patch 的操作类型（RFC 6902）
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+	PatchMove    = "move"
+	PatchCopy    = "copy"
+	PatchTest    = "test"
+)
+
+// 比较两个JSON数据，生成 RFC 6902 的 JSON Patch，每个操作为 {op, path, value}
+// 对象按键排序比较，数组按下标比较，多出的元素追加，缺少的元素从末尾删除
+func (p *jsonStruct) Diff(a, b interface{}) []interface{} {
+	return diffValue(convertValue(a), convertValue(b), "", []interface{}{})
+}
+
+// 将 JSON Patch 应用到数据上，返回新的数据，原数据不修改
+// patch 可以是 []interface{}、[]map[string]interface{} 或JSON字符串；任一操作失败时返回错误，不返回部分结果
+func (p *jsonStruct) ApplyPatch(doc interface{}, patch interface{}) (interface{}, error) {
+	operations, err := patchOperations(patch)
+	if err != nil {
+		return nil, err
+	}
+	result := convertValue(doc)
+	for i, operation := range operations {
+		result, err = applyOperation(result, operation)
+		if err != nil {
+			return nil, fmt.Errorf("json patch operation %d (%v %v): %w", i, operation["op"], operation["path"], err)
+		}
+	}
+	return result, nil
+}
+
+// 按 RFC 7386 合并数据：patch 中值为 null 的键被删除，对象递归合并，其他值直接替换
+// 返回新的数据，原数据不修改
+func (p *jsonStruct) MergePatch(target, patch interface{}) interface{} {
+	return mergePatch(convertValue(target), convertValue(patch))
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	patchMap, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetMap, ok := target.(map[string]interface{})
+	if !ok {
+		targetMap = map[string]interface{}{}
+	}
+	for key, value := range patchMap {
+		if value == nil {
+			delete(targetMap, key)
+			continue
+		}
+		targetMap[key] = mergePatch(targetMap[key], value)
+	}
+	return targetMap
+}
+
+func diffValue(a, b interface{}, path string, patch []interface{}) []interface{} {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		if bv, ok := b.(map[string]interface{}); ok {
+			return diffMap(av, bv, path, patch)
+		}
+	case []interface{}:
+		if bv, ok := b.([]interface{}); ok {
+			return diffSlice(av, bv, path, patch)
+		}
+	}
+	if patchEqual(a, b) {
+		return patch
+	}
+	return append(patch, patchOperation(PatchReplace, path, b))
+}
+
+func diffMap(a, b map[string]interface{}, path string, patch []interface{}) []interface{} {
+	keys := make([]string, 0, len(a))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		childPath := path + "/" + escapePointer(key)
+		if bv, ok := b[key]; ok {
+			patch = diffValue(a[key], bv, childPath, patch)
+		} else {
+			patch = append(patch, map[string]interface{}{"op": PatchRemove, "path": childPath})
+		}
+	}
+
+	keys = keys[:0]
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		patch = append(patch, patchOperation(PatchAdd, path+"/"+escapePointer(key), b[key]))
+	}
+	return patch
+}
+
+func diffSlice(a, b []interface{}, path string, patch []interface{}) []interface{} {
+	common := len(a)
+	if len(b) < common {
+		common = len(b)
+	}
+	for i := 0; i < common; i++ {
+		patch = diffValue(a[i], b[i], path+"/"+strconv.Itoa(i), patch)
+	}
+	// 从末尾删除，保证前面的下标不变
+	for i := len(a) - 1; i >= common; i-- {
+		patch = append(patch, map[string]interface{}{"op": PatchRemove, "path": path + "/" + strconv.Itoa(i)})
+	}
+	for i := common; i < len(b); i++ {
+		patch = append(patch, patchOperation(PatchAdd, path+"/"+strconv.Itoa(i), b[i]))
+	}
+	return patch
+}
+
+func patchOperation(op, path string, value interface{}) map[string]interface{} {
+	return map[string]interface{}{"op": op, "path": path, "value": convertValue(value)}
+}
+
+// 统一 patch 的格式
+func patchOperations(patch interface{}) ([]map[string]interface{}, error) {
+	switch v := patch.(type) {
+	case string, []byte:
+		parsed, ok := JSON.Parse(v)
+		if !ok {
+			return nil, errors.New("invalid json patch: " + fmt.Sprint(parsed))
+		}
+		return patchOperations(parsed)
+	case []map[string]interface{}:
+		return v, nil
+	case []interface{}:
+		operations := make([]map[string]interface{}, len(v))
+		for i, item := range v {
+			operation, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid json patch: operation %d is not an object", i)
+			}
+			operations[i] = operation
+		}
+		return operations, nil
+	}
+	return nil, fmt.Errorf("invalid json patch: %T", patch)
+}
+
+func applyOperation(doc interface{}, operation map[string]interface{}) (interface{}, error) {
+	op, _ := operation["op"].(string)
+	path, ok := operation["path"].(string)
+	if !ok {
+		return nil, errors.New("missing path")
+	}
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+	value, hasValue := operation["value"]
+	if !hasValue && (op == PatchAdd || op == PatchReplace || op == PatchTest) {
+		return nil, errors.New("missing value")
+	}
+
+	switch op {
+	case PatchAdd:
+		return addByPointer(doc, tokens, convertValue(value))
+	case PatchRemove:
+		doc, _, err = removeByPointer(doc, tokens)
+		return doc, err
+	case PatchReplace:
+		if doc, _, err = removeByPointer(doc, tokens); err != nil {
+			return nil, err
+		}
+		return addByPointer(doc, tokens, convertValue(value))
+	case PatchTest:
+		current, err := getByPointer(doc, tokens)
+		if err != nil {
+			return nil, err
+		}
+		if !patchEqual(current, value) {
+			return nil, errors.New("test failed")
+		}
+		return doc, nil
+	case PatchMove, PatchCopy:
+		from, ok := operation["from"].(string)
+		if !ok {
+			return nil, errors.New("missing from")
+		}
+		fromTokens, err := parsePointer(from)
+		if err != nil {
+			return nil, err
+		}
+		if op == PatchMove {
+			if from == path {
+				return doc, nil
+			}
+			if strings.HasPrefix(path, from+"/") {
+				return nil, errors.New("cannot move a value into its own child")
+			}
+			var moved interface{}
+			if doc, moved, err = removeByPointer(doc, fromTokens); err != nil {
+				return nil, err
+			}
+			return addByPointer(doc, tokens, moved)
+		}
+		copied, err := getByPointer(doc, fromTokens)
+		if err != nil {
+			return nil, err
+		}
+		return addByPointer(doc, tokens, convertValue(copied))
+	}
+	return nil, errors.New("unknown op: " + op)
+}
+
+// 解析 JSON Pointer（RFC 6901），"" 表示根节点
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, errors.New("invalid json pointer: " + pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// 数组下标，allowEnd 为 true 时允许 len 或 "-"（用于 add）
+func pointerIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, errors.New("invalid array index: " + token)
+	}
+	if index > length || (index == length && !allowEnd) {
+		return 0, errors.New("array index out of range: " + token)
+	}
+	return index, nil
+}
+
+func getByPointer(doc interface{}, tokens []string) (interface{}, error) {
+	current := doc
+	for _, token := range tokens {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, errors.New("path not found: " + token)
+			}
+			current = value
+		case []interface{}:
+			index, err := pointerIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			current = node[index]
+		default:
+			return nil, errors.New("path not found: " + token)
+		}
+	}
+	return current, nil
+}
+
+// 找到父节点后调用 fn 修改，数组长度变化后逐层写回
+func updateParent(node interface{}, tokens []string, fn func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return fn(node, tokens[0])
+	}
+	token := tokens[0]
+	switch parent := node.(type) {
+	case map[string]interface{}:
+		child, ok := parent[token]
+		if !ok {
+			return nil, errors.New("path not found: " + token)
+		}
+		newChild, err := updateParent(child, tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		parent[token] = newChild
+		return parent, nil
+	case []interface{}:
+		index, err := pointerIndex(token, len(parent), false)
+		if err != nil {
+			return nil, err
+		}
+		newChild, err := updateParent(parent[index], tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		parent[index] = newChild
+		return parent, nil
+	}
+	return nil, errors.New("path not found: " + token)
+}
+
+func addByPointer(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			index, err := pointerIndex(token, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		}
+		return nil, errors.New("path not found: " + token)
+	})
+}
+
+func removeByPointer(doc interface{}, tokens []string) (interface{}, interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, doc, nil
+	}
+	var removed interface{}
+	result, err := updateParent(doc, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, errors.New("path not found: " + token)
+			}
+			removed = value
+			delete(node, token)
+			return node, nil
+		case []interface{}:
+			index, err := pointerIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[index]
+			return append(node[:index], node[index+1:]...), nil
+		}
+		return nil, errors.New("path not found: " + token)
+	})
+	return result, removed, err
+}
+
+// 比较两个JSON数据是否相等，数字按数值比较
+func patchEqual(a, b interface{}) bool {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, value := range av {
+			other, ok := bv[key]
+			if !ok || !patchEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !patchEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return valueEqual(a, b)
+}
@@ -0,0 +1,137 @@
+package json
+
+import (
+	stdjson "encoding/json"
+	"reflect"
+	"testing"
+)
+
+func parseTestJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := stdjson.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("invalid test json %s: %v", s, err)
+	}
+	return v
+}
+
+// RFC 6902 附录A的示例，A.13 为重复键的JSON，不适用
+func TestApplyPatchRFC6902(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string // 为空时期望返回错误
+	}{
+		{"A.1 add object member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"A.2 add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"A.3 remove object member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"A.4 remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"A.5 replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"A.6 move value", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"A.7 move array element", `{"foo":["all","grass","cows","eat"]}`,
+			`[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"A.8 test success", `{"baz":"qux","foo":["a",2,"c"]}`,
+			`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{"A.9 test failure", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, ``},
+		{"A.10 add nested member", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{"A.11 ignore unrecognized elements", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux","xyz":123}]`, `{"foo":"bar","baz":"qux"}`},
+		{"A.12 add to nonexistent target", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, ``},
+		{"A.14 escape ordering", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`},
+		{"A.15 compare strings and numbers", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":"10"}]`, ``},
+		{"A.16 add array value", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{"copy", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"}]`, `{"a":{"b":1},"c":{"b":1}}`},
+		{"replace root", `{"a":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+		// 无效路径
+		{"index out of range", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/2","value":"x"}]`, ``},
+		{"index with leading zero", `{"foo":["bar","baz"]}`, `[{"op":"remove","path":"/foo/01"}]`, ``},
+		{"remove missing member", `{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, ``},
+		{"path without slash", `{"foo":"bar"}`, `[{"op":"replace","path":"foo","value":1}]`, ``},
+		{"move into own child", `{"a":{"b":{}}}`, `[{"op":"move","from":"/a","path":"/a/b/c"}]`, ``},
+		{"unknown op", `{"foo":"bar"}`, `[{"op":"merge","path":"/foo","value":1}]`, ``},
+		// 任一操作失败时不返回部分结果
+		{"failure after success", `{"foo":"bar"}`, `[{"op":"add","path":"/a","value":1},{"op":"test","path":"/a","value":2}]`, ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseTestJSON(t, tt.doc)
+			got, err := JSON.ApplyPatch(doc, tt.patch)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("ApplyPatch = %#v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := parseTestJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Fatalf("ApplyPatch = %#v, want %#v", got, want)
+			}
+			if !reflect.DeepEqual(doc, parseTestJSON(t, tt.doc)) {
+				t.Fatalf("ApplyPatch modified the document: %#v", doc)
+			}
+		})
+	}
+}
+
+// RFC 7386 附录A的示例
+func TestMergePatchRFC7386(t *testing.T) {
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		target := parseTestJSON(t, tt.target)
+		got := JSON.MergePatch(target, parseTestJSON(t, tt.patch))
+		if want := parseTestJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+			t.Fatalf("MergePatch(%s, %s) = %#v, want %s", tt.target, tt.patch, got, tt.want)
+		}
+		if !reflect.DeepEqual(target, parseTestJSON(t, tt.target)) {
+			t.Fatalf("MergePatch modified the target: %#v", target)
+		}
+	}
+}
+
+// Diff 生成的补丁应用到 a 上得到 b
+func TestDiffRoundTrip(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{`{"a":1,"b":{"c":[1,2,3]}}`, `{"a":2,"b":{"c":[1,4]},"d":null}`},
+		{`{"list":[1]}`, `{"list":[1,{"x":"~/"},3]}`},
+		{`{"a/b":1,"m~n":2}`, `{"a/b":3}`},
+		{`[1,2]`, `{"a":1}`},
+		{`{"same":true}`, `{"same":true}`},
+	}
+	for _, tt := range tests {
+		a, b := parseTestJSON(t, tt.a), parseTestJSON(t, tt.b)
+		patch := JSON.Diff(a, b)
+		got, err := JSON.ApplyPatch(a, patch)
+		if err != nil {
+			t.Fatalf("ApplyPatch(Diff(%s, %s)): %v", tt.a, tt.b, err)
+		}
+		if !reflect.DeepEqual(got, b) {
+			t.Fatalf("ApplyPatch(Diff(%s, %s)) = %#v, patch %#v", tt.a, tt.b, got, patch)
+		}
+	}
+	if patch := JSON.Diff(parseTestJSON(t, `{"same":true}`), parseTestJSON(t, `{"same":true}`)); len(patch) != 0 {
+		t.Fatalf("Diff of equal values = %#v, want empty", patch)
+	}
+}
@@ -0,0 +1,853 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// 编译缓存的最大路径数，超出时清空重建
+const maxQueryCache = 1000
+
+var queryCache = make(map[string]*jsonPath)
+var queryCacheMutex = &sync.RWMutex{}
+
+// Query 按 JSONPath 查询数据，返回所有匹配的值
+// 支持：$ 根节点，.name 与 ['name'] 子节点，.* 与 [*] 通配，..name 递归查找，
+// [0]、[-1] 下标，[0,2] 多选，[1:5:2] 切片，[?(@.Type=='Device' && @.Count > 1)] 过滤
+// 路径不以 $ 开头时视为相对根节点，如 "items[*].id"；编译后的路径会被缓存
+func (p *jsonStruct) Query(data interface{}, path string) ([]interface{}, error) {
+	compiled, err := compileQuery(path)
+	if err != nil {
+		return nil, err
+	}
+	return compiled.query(data, data), nil
+}
+
+func compileQuery(path string) (*jsonPath, error) {
+	queryCacheMutex.RLock()
+	compiled, ok := queryCache[path]
+	queryCacheMutex.RUnlock()
+	if ok {
+		return compiled, nil
+	}
+
+	parser := &queryParser{src: strings.TrimSpace(path)}
+	compiled, err := parser.parseQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	queryCacheMutex.Lock()
+	if len(queryCache) >= maxQueryCache {
+		queryCache = make(map[string]*jsonPath)
+	}
+	queryCache[path] = compiled
+	queryCacheMutex.Unlock()
+	return compiled, nil
+}
+
+// jsonPath 编译后的路径
+type jsonPath struct {
+	relative bool // @ 开头，相对于过滤器的当前节点
+	segments []pathSegment
+}
+
+type pathSegment struct {
+	recursive bool // ..
+	selectors []pathSelector
+}
+
+type pathSelector interface {
+	selectFrom(node, root interface{}, results []interface{}) []interface{}
+}
+
+func (jp *jsonPath) query(current, root interface{}) []interface{} {
+	nodes := []interface{}{current}
+	for _, segment := range jp.segments {
+		var next []interface{}
+		for _, node := range nodes {
+			candidates := []interface{}{node}
+			if segment.recursive {
+				candidates = descendants(node, candidates)
+			}
+			for _, candidate := range candidates {
+				for _, selector := range segment.selectors {
+					next = selector.selectFrom(candidate, root, next)
+				}
+			}
+		}
+		nodes = next
+		if len(nodes) == 0 {
+			break
+		}
+	}
+	if nodes == nil {
+		nodes = []interface{}{}
+	}
+	return nodes
+}
+
+type nameSelector struct {
+	name string
+}
+
+func (s nameSelector) selectFrom(node, root interface{}, results []interface{}) []interface{} {
+	if value, ok := childByName(node, s.name); ok {
+		results = append(results, value)
+	}
+	return results
+}
+
+type wildcardSelector struct{}
+
+func (s wildcardSelector) selectFrom(node, root interface{}, results []interface{}) []interface{} {
+	return append(results, childrenOf(node)...)
+}
+
+type indexSelector struct {
+	index int
+}
+
+func (s indexSelector) selectFrom(node, root interface{}, results []interface{}) []interface{} {
+	elements, ok := elementsOf(node)
+	if !ok {
+		return results
+	}
+	index := s.index
+	if index < 0 {
+		index += len(elements)
+	}
+	if index >= 0 && index < len(elements) {
+		results = append(results, elements[index])
+	}
+	return results
+}
+
+type sliceSelector struct {
+	start, end *int
+	step       int
+}
+
+func (s sliceSelector) selectFrom(node, root interface{}, results []interface{}) []interface{} {
+	elements, ok := elementsOf(node)
+	if !ok || s.step == 0 {
+		return results
+	}
+	length := len(elements)
+	normalize := func(i *int, def int) int {
+		if i == nil {
+			return def
+		}
+		n := *i
+		if n < 0 {
+			n += length
+		}
+		return n
+	}
+	if s.step > 0 {
+		start := clamp(normalize(s.start, 0), 0, length)
+		end := clamp(normalize(s.end, length), 0, length)
+		for i := start; i < end; i += s.step {
+			results = append(results, elements[i])
+		}
+	} else {
+		start := clamp(normalize(s.start, length-1), -1, length-1)
+		end := clamp(normalize(s.end, -length-1), -1, length-1)
+		for i := start; i > end; i += s.step {
+			results = append(results, elements[i])
+		}
+	}
+	return results
+}
+
+func clamp(n, min, max int) int {
+	if n < min {
+		return min
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+type filterSelector struct {
+	expr filterExpr
+}
+
+func (s filterSelector) selectFrom(node, root interface{}, results []interface{}) []interface{} {
+	for _, child := range childrenOf(node) {
+		if truthy(s.expr.eval(child, root)) {
+			results = append(results, child)
+		}
+	}
+	return results
+}
+
+// 按名称获取对象的属性
+func childByName(node interface{}, name string) (interface{}, bool) {
+	switch m := node.(type) {
+	case map[string]interface{}:
+		value, ok := m[name]
+		return value, ok
+	case map[string]string:
+		value, ok := m[name]
+		return value, ok
+	case *sync.Map:
+		return m.Load(name)
+	case nil:
+		return nil, false
+	}
+	v := reflect.ValueOf(node)
+	if v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String {
+		value := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		if value.IsValid() {
+			return value.Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// 获取数组的元素
+func elementsOf(node interface{}) ([]interface{}, bool) {
+	switch s := node.(type) {
+	case []interface{}:
+		return s, true
+	case []map[string]interface{}:
+		elements := make([]interface{}, len(s))
+		for i, item := range s {
+			elements[i] = item
+		}
+		return elements, true
+	case nil, string, []byte:
+		return nil, false
+	}
+	v := reflect.ValueOf(node)
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		elements := make([]interface{}, v.Len())
+		for i := range elements {
+			elements[i] = v.Index(i).Interface()
+		}
+		return elements, true
+	}
+	return nil, false
+}
+
+// 获取对象或数组的所有子节点，对象按 key 排序
+func childrenOf(node interface{}) []interface{} {
+	if elements, ok := elementsOf(node); ok {
+		return elements
+	}
+	keys := objectKeys(node)
+	children := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		value, _ := childByName(node, key)
+		children = append(children, value)
+	}
+	return children
+}
+
+func objectKeys(node interface{}) []string {
+	var keys []string
+	switch m := node.(type) {
+	case map[string]interface{}:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]string:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case *sync.Map:
+		m.Range(func(key, value interface{}) bool {
+			if k, ok := key.(string); ok {
+				keys = append(keys, k)
+			}
+			return true
+		})
+	case nil:
+		return nil
+	default:
+		v := reflect.ValueOf(node)
+		if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		for _, key := range v.MapKeys() {
+			keys = append(keys, key.String())
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// 节点及其所有子孙节点，先序遍历
+func descendants(node interface{}, results []interface{}) []interface{} {
+	for _, child := range childrenOf(node) {
+		results = append(results, child)
+		results = descendants(child, results)
+	}
+	return results
+}
+
+// ==== 过滤表达式 ====
+
+type filterExpr interface {
+	// eval 返回表达式的值，路径不存在时返回 missing
+	eval(current, root interface{}) interface{}
+}
+
+// 路径不存在时的值，与 null 区分
+type missingValue struct{}
+
+var missing = missingValue{}
+
+type literalExpr struct {
+	value interface{}
+}
+
+func (e literalExpr) eval(current, root interface{}) interface{} {
+	return e.value
+}
+
+type pathExpr struct {
+	path *jsonPath
+}
+
+func (e pathExpr) eval(current, root interface{}) interface{} {
+	start := root
+	if e.path.relative {
+		start = current
+	}
+	results := e.path.query(start, root)
+	if len(results) == 0 {
+		return missing
+	}
+	return results[0]
+}
+
+type notExpr struct {
+	expr filterExpr
+}
+
+func (e notExpr) eval(current, root interface{}) interface{} {
+	return !truthy(e.expr.eval(current, root))
+}
+
+type binaryExpr struct {
+	op          string
+	left, right filterExpr
+}
+
+func (e binaryExpr) eval(current, root interface{}) interface{} {
+	switch e.op {
+	case "&&":
+		return truthy(e.left.eval(current, root)) && truthy(e.right.eval(current, root))
+	case "||":
+		return truthy(e.left.eval(current, root)) || truthy(e.right.eval(current, root))
+	}
+	left := e.left.eval(current, root)
+	right := e.right.eval(current, root)
+	if left == missing || right == missing {
+		return e.op == "!=" && (left == missing) != (right == missing)
+	}
+	switch e.op {
+	case "==":
+		return valueEqual(left, right)
+	case "!=":
+		return !valueEqual(left, right)
+	case "=~":
+		re, ok := right.(*regexp.Regexp)
+		s, isString := left.(string)
+		return ok && isString && re.MatchString(s)
+	}
+	cmp, ok := compareValues(left, right)
+	if !ok {
+		return false
+	}
+	switch e.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// 过滤结果是否成立：存在的非 false/null 值为真
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case missingValue, nil:
+		return false
+	case bool:
+		return v
+	}
+	return true
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case interface{ Float64() (float64, error) }:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// 整数按 int64 比较，避免大整数（如 json.Number 的ID）经过 float64 后相等
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint32:
+		return int64(v), true
+	case interface{ Int64() (int64, error) }:
+		i, err := v.Int64()
+		return i, err == nil
+	}
+	return 0, false
+}
+
+func valueEqual(left, right interface{}) bool {
+	if l, ok := toInt64(left); ok {
+		if r, ok := toInt64(right); ok {
+			return l == r
+		}
+	}
+	if l, ok := toFloat(left); ok {
+		r, ok := toFloat(right)
+		return ok && l == r
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+func compareValues(left, right interface{}) (int, bool) {
+	if l, ok := toInt64(left); ok {
+		if r, ok := toInt64(right); ok {
+			switch {
+			case l < r:
+				return -1, true
+			case l > r:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+	if l, ok := toFloat(left); ok {
+		r, ok := toFloat(right)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case l < r:
+			return -1, true
+		case l > r:
+			return 1, true
+		}
+		return 0, true
+	}
+	l, ok1 := left.(string)
+	r, ok2 := right.(string)
+	if ok1 && ok2 {
+		return strings.Compare(l, r), true
+	}
+	return 0, false
+}
+
+// ==== 解析 ====
+
+type queryParser struct {
+	src string
+	pos int
+}
+
+func (qp *queryParser) errorf(format string, args ...interface{}) error {
+	return errors.New("invalid json path " + strconv.Quote(qp.src) + " at " + strconv.Itoa(qp.pos) + ": " + fmt.Sprintf(format, args...))
+}
+
+func (qp *queryParser) peek() byte {
+	if qp.pos < len(qp.src) {
+		return qp.src[qp.pos]
+	}
+	return 0
+}
+
+func (qp *queryParser) skipSpaces() {
+	for qp.pos < len(qp.src) && (qp.src[qp.pos] == ' ' || qp.src[qp.pos] == '\t') {
+		qp.pos++
+	}
+}
+
+func (qp *queryParser) consume(s string) bool {
+	if strings.HasPrefix(qp.src[qp.pos:], s) {
+		qp.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (qp *queryParser) parseQuery() (*jsonPath, error) {
+	if qp.src == "" {
+		return nil, qp.errorf("empty path")
+	}
+	if qp.peek() != '$' && qp.peek() != '.' && qp.peek() != '[' {
+		// 相对根节点的简写
+		qp.src = "$." + qp.src
+	}
+	path, err := qp.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	if qp.pos < len(qp.src) {
+		return nil, qp.errorf("unexpected %q", qp.src[qp.pos:])
+	}
+	return path, nil
+}
+
+// 解析路径，遇到无法识别的字符时停止，由调用方判断
+func (qp *queryParser) parsePath() (*jsonPath, error) {
+	path := &jsonPath{}
+	switch qp.peek() {
+	case '$':
+		qp.pos++
+	case '@':
+		qp.pos++
+		path.relative = true
+	}
+	for qp.pos < len(qp.src) {
+		var segment pathSegment
+		switch {
+		case qp.consume(".."):
+			segment.recursive = true
+			if qp.peek() == '[' {
+				selectors, err := qp.parseBracket()
+				if err != nil {
+					return nil, err
+				}
+				segment.selectors = selectors
+			} else {
+				selector, err := qp.parseDotName()
+				if err != nil {
+					return nil, err
+				}
+				segment.selectors = []pathSelector{selector}
+			}
+		case qp.consume("."):
+			selector, err := qp.parseDotName()
+			if err != nil {
+				return nil, err
+			}
+			segment.selectors = []pathSelector{selector}
+		case qp.peek() == '[':
+			selectors, err := qp.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			segment.selectors = selectors
+		default:
+			return path, nil
+		}
+		path.segments = append(path.segments, segment)
+	}
+	return path, nil
+}
+
+func (qp *queryParser) parseDotName() (pathSelector, error) {
+	if qp.consume("*") {
+		return wildcardSelector{}, nil
+	}
+	start := qp.pos
+	for qp.pos < len(qp.src) {
+		r, size := utf8.DecodeRuneInString(qp.src[qp.pos:])
+		if strings.ContainsRune(".[]()=!<>&|, \t'\"", r) {
+			break
+		}
+		qp.pos += size
+	}
+	if qp.pos == start {
+		return nil, qp.errorf("expected property name")
+	}
+	return nameSelector{name: qp.src[start:qp.pos]}, nil
+}
+
+func (qp *queryParser) parseBracket() ([]pathSelector, error) {
+	qp.pos++ // [
+	qp.skipSpaces()
+	if qp.consume("?") {
+		qp.skipSpaces()
+		expr, err := qp.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		qp.skipSpaces()
+		if !qp.consume("]") {
+			return nil, qp.errorf("expected ]")
+		}
+		return []pathSelector{filterSelector{expr: expr}}, nil
+	}
+
+	var selectors []pathSelector
+	for {
+		qp.skipSpaces()
+		switch c := qp.peek(); {
+		case c == '*':
+			qp.pos++
+			selectors = append(selectors, wildcardSelector{})
+		case c == '\'' || c == '"':
+			name, err := qp.parseString()
+			if err != nil {
+				return nil, err
+			}
+			selectors = append(selectors, nameSelector{name: name})
+		case c == '-' || c == ':' || (c >= '0' && c <= '9'):
+			selector, err := qp.parseIndexOrSlice()
+			if err != nil {
+				return nil, err
+			}
+			selectors = append(selectors, selector)
+		default:
+			return nil, qp.errorf("unexpected selector")
+		}
+		qp.skipSpaces()
+		if qp.consume("]") {
+			return selectors, nil
+		}
+		if !qp.consume(",") {
+			return nil, qp.errorf("expected , or ]")
+		}
+	}
+}
+
+func (qp *queryParser) parseIndexOrSlice() (pathSelector, error) {
+	var parts [3]*int
+	count := 0
+	for count < 3 {
+		qp.skipSpaces()
+		start := qp.pos
+		if qp.peek() == '-' {
+			qp.pos++
+		}
+		for qp.pos < len(qp.src) && qp.src[qp.pos] >= '0' && qp.src[qp.pos] <= '9' {
+			qp.pos++
+		}
+		if qp.pos > start {
+			n, err := strconv.Atoi(qp.src[start:qp.pos])
+			if err != nil {
+				return nil, qp.errorf("invalid index")
+			}
+			parts[count] = &n
+		}
+		count++
+		qp.skipSpaces()
+		if !qp.consume(":") {
+			break
+		}
+	}
+	if count == 1 {
+		if parts[0] == nil {
+			return nil, qp.errorf("expected index")
+		}
+		return indexSelector{index: *parts[0]}, nil
+	}
+	step := 1
+	if parts[2] != nil {
+		step = *parts[2]
+	}
+	return sliceSelector{start: parts[0], end: parts[1], step: step}, nil
+}
+
+func (qp *queryParser) parseString() (string, error) {
+	quote := qp.src[qp.pos]
+	qp.pos++
+	var sb strings.Builder
+	for qp.pos < len(qp.src) {
+		c := qp.src[qp.pos]
+		switch {
+		case c == '\\' && qp.pos+1 < len(qp.src):
+			sb.WriteByte(qp.src[qp.pos+1])
+			qp.pos += 2
+		case c == quote:
+			qp.pos++
+			return sb.String(), nil
+		default:
+			sb.WriteByte(c)
+			qp.pos++
+		}
+	}
+	return "", qp.errorf("unterminated string")
+}
+
+func (qp *queryParser) parseOr() (filterExpr, error) {
+	left, err := qp.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		qp.skipSpaces()
+		if !qp.consume("||") {
+			return left, nil
+		}
+		right, err := qp.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op: "||", left: left, right: right}
+	}
+}
+
+func (qp *queryParser) parseAnd() (filterExpr, error) {
+	left, err := qp.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		qp.skipSpaces()
+		if !qp.consume("&&") {
+			return left, nil
+		}
+		right, err := qp.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op: "&&", left: left, right: right}
+	}
+}
+
+func (qp *queryParser) parseComparison() (filterExpr, error) {
+	left, err := qp.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	qp.skipSpaces()
+	for _, op := range []string{"==", "!=", "<=", ">=", "=~", "<", ">"} {
+		if qp.consume(op) {
+			right, err := qp.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			return binaryExpr{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (qp *queryParser) parseUnary() (filterExpr, error) {
+	qp.skipSpaces()
+	if qp.peek() == '!' && !strings.HasPrefix(qp.src[qp.pos:], "!=") {
+		qp.pos++
+		expr, err := qp.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{expr: expr}, nil
+	}
+	return qp.parsePrimary()
+}
+
+func (qp *queryParser) parsePrimary() (filterExpr, error) {
+	qp.skipSpaces()
+	c := qp.peek()
+	switch {
+	case c == '(':
+		qp.pos++
+		expr, err := qp.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		qp.skipSpaces()
+		if !qp.consume(")") {
+			return nil, qp.errorf("expected )")
+		}
+		return expr, nil
+	case c == '@' || c == '$':
+		path, err := qp.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		return pathExpr{path: path}, nil
+	case c == '\'' || c == '"':
+		s, err := qp.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return literalExpr{value: s}, nil
+	case c == '/':
+		return qp.parseRegexp()
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := qp.pos
+		qp.pos++
+		for qp.pos < len(qp.src) && strings.IndexByte("0123456789.eE+-", qp.src[qp.pos]) >= 0 {
+			qp.pos++
+		}
+		// 整数保留为 int64，与 json.Number 的大整数精确比较
+		if i, err := strconv.ParseInt(qp.src[start:qp.pos], 10, 64); err == nil {
+			return literalExpr{value: i}, nil
+		}
+		n, err := strconv.ParseFloat(qp.src[start:qp.pos], 64)
+		if err != nil {
+			return nil, qp.errorf("invalid number")
+		}
+		return literalExpr{value: n}, nil
+	case qp.consume("true"):
+		return literalExpr{value: true}, nil
+	case qp.consume("false"):
+		return literalExpr{value: false}, nil
+	case qp.consume("null"):
+		return literalExpr{value: nil}, nil
+	}
+	return nil, qp.errorf("unexpected filter expression")
+}
+
+// 正则字面量 /pattern/flags，flags 只支持 i
+func (qp *queryParser) parseRegexp() (filterExpr, error) {
+	qp.pos++ // /
+	start := qp.pos
+	for qp.pos < len(qp.src) && qp.src[qp.pos] != '/' {
+		if qp.src[qp.pos] == '\\' {
+			qp.pos++
+		}
+		qp.pos++
+	}
+	if qp.pos >= len(qp.src) {
+		return nil, qp.errorf("unterminated regexp")
+	}
+	pattern := qp.src[start:qp.pos]
+	qp.pos++
+	if qp.consume("i") {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, qp.errorf("invalid regexp: %v", err)
+	}
+	return literalExpr{value: re}, nil
+}
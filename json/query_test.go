@@ -0,0 +1,74 @@
+package json
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Goessner 的 JSONPath 示例数据
+func queryStore() map[string]interface{} {
+	return map[string]interface{}{
+		"store": map[string]interface{}{
+			"book": []interface{}{
+				map[string]interface{}{"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+				map[string]interface{}{"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+				map[string]interface{}{"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+				map[string]interface{}{"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99},
+			},
+			"bicycle": map[string]interface{}{"color": "red", "price": 19.95},
+		},
+		"expensive": 10,
+	}
+}
+
+func TestQuery(t *testing.T) {
+	data := queryStore()
+	tests := []struct {
+		name string
+		path string
+		want []interface{}
+	}{
+		{"child", "$.store.bicycle.color", []interface{}{"red"}},
+		{"bracket name", "$['store']['bicycle']['price']", []interface{}{19.95}},
+		{"relative to root", "store.book[0].author", []interface{}{"Nigel Rees"}},
+		{"wildcard", "$.store.book[*].author", []interface{}{"Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"}},
+		{"object wildcard sorted by key", "$.store.bicycle.*", []interface{}{"red", 19.95}},
+		{"recursive", "$..price", []interface{}{19.95, 8.95, 12.99, 8.99, 22.99}},
+		{"recursive name", "$.store..isbn", []interface{}{"0-553-21311-3", "0-395-19395-8"}},
+		{"negative index", "$..book[-1].title", []interface{}{"The Lord of the Rings"}},
+		{"union", "$..book[0,2].title", []interface{}{"Sayings of the Century", "Moby Dick"}},
+		{"slice", "$..book[1:3].title", []interface{}{"Sword of Honour", "Moby Dick"}},
+		{"slice open start", "$..book[:2].price", []interface{}{8.95, 12.99}},
+		{"slice step", "$..book[::2].title", []interface{}{"Sayings of the Century", "Moby Dick"}},
+		{"slice negative step", "$..book[::-1].price", []interface{}{22.99, 8.99, 12.99, 8.95}},
+		{"slice out of range", "$..book[10:20]", nil},
+		{"filter exists", "$..book[?(@.isbn)].title", []interface{}{"Moby Dick", "The Lord of the Rings"}},
+		{"filter compare root", "$..book[?(@.price < $.expensive)].title", []interface{}{"Sayings of the Century", "Moby Dick"}},
+		{"filter and", "$..book[?(@.category == 'fiction' && @.price > 10)].author", []interface{}{"Evelyn Waugh", "J. R. R. Tolkien"}},
+		{"filter or not", "$..book[?(!(@.category == 'fiction') || @.price > 20)].price", []interface{}{8.95, 22.99}},
+		{"filter regex", "$..book[?(@.author =~ /^J\\. R/)].title", []interface{}{"The Lord of the Rings"}},
+		{"missing", "$.store.car", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSON.Query(data, tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Query(%s) = %#v, want %#v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	for _, path := range []string{"", "$.", "$[", "$['a", "$[1:x]", "$[?(@.a == )]", "$.a]"} {
+		if got, err := JSON.Query(queryStore(), path); err == nil {
+			t.Fatalf("Query(%q) = %#v, want error", path, got)
+		}
+	}
+}
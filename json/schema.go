@@ -0,0 +1,673 @@
+package json
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// SchemaError 字段级的校验错误，Path 为 JSON Pointer 形式，如 /user/age
+type SchemaError struct {
+	Path    string
+	Keyword string // 未通过的关键字，如 required、type、minimum
+	Message string
+}
+
+func (e *SchemaError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + e.Message
+}
+
+// SchemaErrors 校验失败时返回的全部错误
+type SchemaErrors []*SchemaError
+
+func (e SchemaErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+var schemaPatternCache = &sync.Map{}
+
+// 根据 JSON Schema（draft 2020-12 的子集）校验数据，通过时返回 nil，否则返回 SchemaErrors
+// 支持 type、enum、const、properties、required、additionalProperties、patternProperties、
+// items、prefixItems、长度/数量/数值范围、pattern、format、allOf/anyOf/oneOf/not 以及本地的 $ref
+func (p *jsonStruct) ValidateSchema(data interface{}, schema map[string]interface{}) error {
+	v := &schemaValidator{root: schema}
+	v.validate(convertValue(data), schema, "")
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return v.errors
+}
+
+// 按 schema 中的 default 补充缺少的属性，返回新的数据
+func (p *jsonStruct) ApplySchemaDefaults(data interface{}, schema map[string]interface{}) interface{} {
+	return applySchemaDefaults(convertValue(data), schema, schema)
+}
+
+// ParseParamsWithSchema 与 ParseParamsE 相同，但会校验参数并返回字段级的错误
+// schema 为 nil 时根据 plugParams 的结构体标签生成（见 SchemaOf）
+// defaultParams 与 schema 中的 default 只填充未传入、为 null 或空字符串的参数（与 ParseParamsE 相同），支持布尔、浮点数、嵌套结构体和指针
+func (p *jsonStruct) ParseParamsWithSchema(
+	plugParams any,
+	actionParams, defaultParams map[string]interface{},
+	schema map[string]interface{}) error {
+	if schema == nil {
+		schema = p.SchemaOf(plugParams)
+	}
+
+	var params interface{} = map[string]interface{}{}
+	if actionParams != nil {
+		params = convertValue(actionParams)
+	}
+	if defaultParams != nil {
+		params = mergeDefaults(params, convertValue(defaultParams))
+	}
+	params = applySchemaDefaults(params, schema, schema)
+
+	if err := p.ValidateSchema(params, schema); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(b, plugParams); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return SchemaErrors{{
+				Path:    "/" + strings.ReplaceAll(typeErr.Field, ".", "/"),
+				Keyword: "type",
+				Message: "cannot convert " + typeErr.Value + " to " + typeErr.Type.String(),
+			}}
+		}
+		return err
+	}
+	return nil
+}
+
+// 将默认值填充到未传入、为 null 或空字符串的参数中，对象递归填充
+func mergeDefaults(params, defaults interface{}) interface{} {
+	if isMissingValue(params) {
+		return defaults
+	}
+	paramsMap, ok := params.(map[string]interface{})
+	defaultsMap, ok2 := defaults.(map[string]interface{})
+	if !ok || !ok2 {
+		return params
+	}
+	for key, value := range defaultsMap {
+		paramsMap[key] = mergeDefaults(paramsMap[key], value)
+	}
+	return paramsMap
+}
+
+func applySchemaDefaults(data interface{}, schema, root map[string]interface{}) interface{} {
+	if ref, ok := schema["$ref"].(string); ok {
+		if target, err := resolveSchemaRef(root, ref); err == nil {
+			schema = target
+		}
+	}
+	switch node := data.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		for key, prop := range properties {
+			propSchema, ok := prop.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if isMissingValue(node[key]) {
+				if def, ok := propSchema["default"]; ok {
+					node[key] = convertValue(JSON.Clone(def))
+				}
+			}
+			if value, exists := node[key]; exists {
+				node[key] = applySchemaDefaults(value, propSchema, root)
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range node {
+				node[i] = applySchemaDefaults(item, items, root)
+			}
+		}
+	}
+	return data
+}
+
+type schemaValidator struct {
+	root   map[string]interface{}
+	errors SchemaErrors
+}
+
+func (v *schemaValidator) addError(path, keyword, format string, args ...interface{}) {
+	v.errors = append(v.errors, &SchemaError{Path: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+}
+
+// 校验子 schema 但不记录错误，用于 anyOf/oneOf/not
+func (v *schemaValidator) matches(data interface{}, schema interface{}, path string) bool {
+	sub := &schemaValidator{root: v.root}
+	sub.validate(data, schema, path)
+	return len(sub.errors) == 0
+}
+
+func (v *schemaValidator) validate(data interface{}, schemaValue interface{}, path string) {
+	switch s := schemaValue.(type) {
+	case bool:
+		if !s {
+			v.addError(path, "false", "no value is allowed")
+		}
+		return
+	case map[string]interface{}:
+	default:
+		return
+	}
+	schema := schemaValue.(map[string]interface{})
+
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := resolveSchemaRef(v.root, ref)
+		if err != nil {
+			v.addError(path, "$ref", "%s", err.Error())
+			return
+		}
+		v.validate(data, target, path)
+	}
+
+	if types, ok := schema["type"]; ok && !matchesType(data, types) {
+		v.addError(path, "type", "expected %s, got %s", typeNames(types), jsonTypeOf(data))
+		return
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, item := range enum {
+			if patchEqual(data, item) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.addError(path, "enum", "value must be one of %s", JSON.Stringify(enum))
+		}
+	}
+	if constValue, ok := schema["const"]; ok && !patchEqual(data, constValue) {
+		v.addError(path, "const", "value must be %s", JSON.Stringify(constValue))
+	}
+
+	switch node := data.(type) {
+	case string:
+		v.validateString(node, schema, path)
+	case map[string]interface{}:
+		v.validateObject(node, schema, path)
+	case []interface{}:
+		v.validateArray(node, schema, path)
+	default:
+		if number, ok := toFloat(data); ok {
+			v.validateNumber(number, schema, path)
+		}
+	}
+
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			v.validate(data, sub, path)
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if v.matches(data, sub, path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.addError(path, "anyOf", "value does not match any schema")
+		}
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		count := 0
+		for _, sub := range oneOf {
+			if v.matches(data, sub, path) {
+				count++
+			}
+		}
+		if count != 1 {
+			v.addError(path, "oneOf", "value must match exactly one schema, matched %d", count)
+		}
+	}
+	if not, ok := schema["not"]; ok && v.matches(data, not, path) {
+		v.addError(path, "not", "value must not match schema")
+	}
+}
+
+func (v *schemaValidator) validateString(s string, schema map[string]interface{}, path string) {
+	length := utf8.RuneCountInString(s)
+	if min, ok := schemaNumber(schema, "minLength"); ok && float64(length) < min {
+		v.addError(path, "minLength", "length must be >= %v", min)
+	}
+	if max, ok := schemaNumber(schema, "maxLength"); ok && float64(length) > max {
+		v.addError(path, "maxLength", "length must be <= %v", max)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		reg, err := compileSchemaPattern(pattern)
+		if err != nil {
+			v.addError(path, "pattern", "invalid pattern %s", pattern)
+		} else if !reg.MatchString(s) {
+			v.addError(path, "pattern", "value must match %s", pattern)
+		}
+	}
+	if format, ok := schema["format"].(string); ok && !matchesFormat(s, format) {
+		v.addError(path, "format", "value must be a valid %s", format)
+	}
+}
+
+func (v *schemaValidator) validateNumber(n float64, schema map[string]interface{}, path string) {
+	if min, ok := schemaNumber(schema, "minimum"); ok && n < min {
+		v.addError(path, "minimum", "value must be >= %v", min)
+	}
+	if max, ok := schemaNumber(schema, "maximum"); ok && n > max {
+		v.addError(path, "maximum", "value must be <= %v", max)
+	}
+	if min, ok := schemaNumber(schema, "exclusiveMinimum"); ok && n <= min {
+		v.addError(path, "exclusiveMinimum", "value must be > %v", min)
+	}
+	if max, ok := schemaNumber(schema, "exclusiveMaximum"); ok && n >= max {
+		v.addError(path, "exclusiveMaximum", "value must be < %v", max)
+	}
+	if multiple, ok := schemaNumber(schema, "multipleOf"); ok && multiple > 0 {
+		if q := n / multiple; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.addError(path, "multipleOf", "value must be a multiple of %v", multiple)
+		}
+	}
+}
+
+func (v *schemaValidator) validateObject(node map[string]interface{}, schema map[string]interface{}, path string) {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, item := range required {
+			key, _ := item.(string)
+			if _, exists := node[key]; !exists {
+				v.addError(path+"/"+escapePointer(key), "required", "is required")
+			}
+		}
+	}
+	if min, ok := schemaNumber(schema, "minProperties"); ok && float64(len(node)) < min {
+		v.addError(path, "minProperties", "must have at least %v properties", min)
+	}
+	if max, ok := schemaNumber(schema, "maxProperties"); ok && float64(len(node)) > max {
+		v.addError(path, "maxProperties", "must have at most %v properties", max)
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	patternProperties, _ := schema["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := schema["additionalProperties"]
+
+	keys := make([]string, 0, len(node))
+	for key := range node {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		childPath := path + "/" + escapePointer(key)
+		matched := false
+		if prop, ok := properties[key]; ok {
+			matched = true
+			v.validate(node[key], prop, childPath)
+		}
+		for pattern, prop := range patternProperties {
+			if reg, err := compileSchemaPattern(pattern); err == nil && reg.MatchString(key) {
+				matched = true
+				v.validate(node[key], prop, childPath)
+			}
+		}
+		if !matched && hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				v.addError(childPath, "additionalProperties", "property is not allowed")
+			} else {
+				v.validate(node[key], additional, childPath)
+			}
+		}
+	}
+}
+
+func (v *schemaValidator) validateArray(node []interface{}, schema map[string]interface{}, path string) {
+	if min, ok := schemaNumber(schema, "minItems"); ok && float64(len(node)) < min {
+		v.addError(path, "minItems", "must have at least %v items", min)
+	}
+	if max, ok := schemaNumber(schema, "maxItems"); ok && float64(len(node)) > max {
+		v.addError(path, "maxItems", "must have at most %v items", max)
+	}
+	if unique, _ := schema["uniqueItems"].(bool); unique {
+	outer:
+		for i := range node {
+			for j := 0; j < i; j++ {
+				if patchEqual(node[i], node[j]) {
+					v.addError(path, "uniqueItems", "items %d and %d are equal", j, i)
+					break outer
+				}
+			}
+		}
+	}
+
+	start := 0
+	if prefixItems, ok := schema["prefixItems"].([]interface{}); ok {
+		for i := 0; i < len(prefixItems) && i < len(node); i++ {
+			v.validate(node[i], prefixItems[i], path+"/"+strconv.Itoa(i))
+		}
+		start = len(prefixItems)
+	}
+	if items, ok := schema["items"]; ok {
+		for i := start; i < len(node); i++ {
+			v.validate(node[i], items, path+"/"+strconv.Itoa(i))
+		}
+	}
+}
+
+// 解析本地引用，如 #/$defs/user
+func resolveSchemaRef(root map[string]interface{}, ref string) (map[string]interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, errors.New("only local $ref is supported: " + ref)
+	}
+	tokens, err := parsePointer(ref[1:])
+	if err != nil {
+		return nil, err
+	}
+	target, err := getByPointer(root, tokens)
+	if err != nil {
+		return nil, errors.New("cannot resolve $ref " + ref)
+	}
+	schema, ok := target.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("cannot resolve $ref " + ref)
+	}
+	return schema, nil
+}
+
+func compileSchemaPattern(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := schemaPatternCache.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	reg, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	schemaPatternCache.Store(pattern, reg)
+	return reg, nil
+}
+
+func schemaNumber(schema map[string]interface{}, keyword string) (float64, bool) {
+	value, ok := schema[keyword]
+	if !ok {
+		return 0, false
+	}
+	return toFloat(value)
+}
+
+func jsonTypeOf(data interface{}) string {
+	switch v := data.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		if n, ok := toFloat(v); ok {
+			if n == math.Trunc(n) && !math.IsInf(n, 0) {
+				return "integer"
+			}
+			return "number"
+		}
+	}
+	return fmt.Sprintf("%T", data)
+}
+
+func matchesType(data interface{}, types interface{}) bool {
+	actual := jsonTypeOf(data)
+	check := func(name string) bool {
+		return name == actual || (name == "number" && actual == "integer")
+	}
+	switch t := types.(type) {
+	case string:
+		return check(t)
+	case []interface{}:
+		for _, item := range t {
+			if name, ok := item.(string); ok && check(name) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func typeNames(types interface{}) string {
+	if list, ok := types.([]interface{}); ok {
+		names := make([]string, len(list))
+		for i, item := range list {
+			names[i] = fmt.Sprint(item)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(types)
+}
+
+// 校验常用的 format，未知的 format 不校验
+func matchesFormat(s, format string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	case "time":
+		_, err := time.Parse("15:04:05Z07:00", s)
+		return err == nil
+	case "email":
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s
+	case "uri":
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != ""
+	case "uuid":
+		_, err := uuid.Parse(s)
+		return err == nil && len(s) == 36
+	case "ipv4":
+		parts := strings.Split(s, ".")
+		if len(parts) != 4 {
+			return false
+		}
+		for _, part := range parts {
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 || n > 255 || (len(part) > 1 && part[0] == '0') {
+				return false
+			}
+		}
+		return true
+	}
+	return true
+}
+
+// 根据结构体生成 JSON Schema，属性名取 json 标签
+// 支持的标签：default:"值"、required:"true"、enum:"a,b,c"、pattern:"正则"、min:"n"、max:"n"
+// min/max 对字符串表示长度，对数组表示元素数量，对数字表示取值范围
+func (p *jsonStruct) SchemaOf(v interface{}) map[string]interface{} {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return map[string]interface{}{}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return schemaOfType(t, map[reflect.Type]bool{})
+}
+
+func schemaOfType(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+	schema := map[string]interface{}{}
+	switch t.Kind() {
+	case reflect.Bool:
+		schema["type"] = "boolean"
+	case reflect.String:
+		schema["type"] = "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema["type"] = "integer"
+	case reflect.Float32, reflect.Float64:
+		schema["type"] = "number"
+	case reflect.Slice, reflect.Array:
+		schema["type"] = "array"
+		schema["items"] = schemaOfType(t.Elem(), visiting)
+	case reflect.Map:
+		schema["type"] = "object"
+		if t.Elem().Kind() != reflect.Interface {
+			schema["additionalProperties"] = schemaOfType(t.Elem(), visiting)
+		}
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			schema["type"] = "string"
+			schema["format"] = "date-time"
+			break
+		}
+		schema["type"] = "object"
+		// 递归的结构体不再展开
+		if visiting[t] {
+			break
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		properties := map[string]interface{}{}
+		var required []interface{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, ok := jsonFieldName(field)
+			if !ok {
+				continue
+			}
+			propSchema := schemaOfType(field.Type, visiting)
+			applyFieldTags(propSchema, field)
+			properties[name] = propSchema
+			if field.Tag.Get("required") == "true" {
+				required = append(required, name)
+			}
+		}
+		schema["properties"] = properties
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+	}
+	if nullable {
+		if name, ok := schema["type"].(string); ok {
+			schema["type"] = []interface{}{name, "null"}
+		}
+	}
+	return schema
+}
+
+// 取字段的 json 名称，未导出或标记为 "-" 的字段返回 false
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" {
+		return "", false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name, true
+	}
+	return field.Name, true
+}
+
+func applyFieldTags(schema map[string]interface{}, field reflect.StructField) {
+	typeName, _ := schema["type"].(string)
+	if list, ok := schema["type"].([]interface{}); ok {
+		typeName, _ = list[0].(string)
+	}
+	if def, ok := field.Tag.Lookup("default"); ok {
+		if value, err := parseTagValue(def, typeName); err == nil {
+			schema["default"] = value
+		}
+	}
+	if enum, ok := field.Tag.Lookup("enum"); ok {
+		var values []interface{}
+		for _, item := range strings.Split(enum, ",") {
+			if value, err := parseTagValue(strings.TrimSpace(item), typeName); err == nil {
+				values = append(values, value)
+			}
+		}
+		schema["enum"] = values
+	}
+	if pattern, ok := field.Tag.Lookup("pattern"); ok {
+		schema["pattern"] = pattern
+	}
+	for tag, keywords := range map[string][3]string{
+		"min": {"minLength", "minItems", "minimum"},
+		"max": {"maxLength", "maxItems", "maximum"},
+	} {
+		value, ok := field.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		switch typeName {
+		case "string":
+			schema[keywords[0]] = n
+		case "array":
+			schema[keywords[1]] = n
+		case "integer", "number":
+			schema[keywords[2]] = n
+		}
+	}
+}
+
+// 将标签中的字符串按类型转换，对象和数组按JSON解析
+func parseTagValue(value, typeName string) (interface{}, error) {
+	switch typeName {
+	case "string":
+		return value, nil
+	case "boolean":
+		return strconv.ParseBool(value)
+	case "integer":
+		n, err := strconv.ParseInt(value, 10, 64)
+		return float64(n), err
+	case "number":
+		return strconv.ParseFloat(value, 64)
+	}
+	result, ok := JSON.Parse(value)
+	if !ok {
+		return nil, errors.New("invalid default value: " + value)
+	}
+	return result, nil
+}
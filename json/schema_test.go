@@ -0,0 +1,123 @@
+package json
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidateSchema(t *testing.T) {
+	user := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"name", "age"},
+		"properties": map[string]interface{}{
+			"name":  map[string]interface{}{"type": "string", "minLength": 2, "maxLength": 5},
+			"age":   map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 150},
+			"email": map[string]interface{}{"type": "string", "format": "email"},
+			"role":  map[string]interface{}{"enum": []interface{}{"admin", "user"}},
+			"tags":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "uniqueItems": true, "maxItems": 2},
+			"code":  map[string]interface{}{"type": "string", "pattern": "^[A-Z]{3}$"},
+		},
+		"additionalProperties": false,
+	}
+	tests := []struct {
+		name   string
+		schema map[string]interface{}
+		data   interface{}
+		want   []string // path keyword
+	}{
+		{"valid", user, map[string]interface{}{"name": "Tom", "age": 30, "email": "a@b.cn", "tags": []interface{}{"a"}}, nil},
+		{"required", user, map[string]interface{}{}, []string{"/name required", "/age required"}},
+		{"type", user, map[string]interface{}{"name": 1, "age": 1.5}, []string{"/age type", "/name type"}},
+		{"range", user, map[string]interface{}{"name": "T", "age": -1}, []string{"/age minimum", "/name minLength"}},
+		{"format enum pattern", user, map[string]interface{}{"name": "Tom", "age": 1, "email": "bad", "role": "root", "code": "ab"},
+			[]string{"/code pattern", "/email format", "/role enum"}},
+		{"items", user, map[string]interface{}{"name": "Tom", "age": 1, "tags": []interface{}{"a", "a", 1}},
+			[]string{"/tags maxItems", "/tags uniqueItems", "/tags/2 type"}},
+		{"additional", user, map[string]interface{}{"name": "Tom", "age": 1, "x": 1}, []string{"/x additionalProperties"}},
+		{"root type", user, []interface{}{}, []string{" type"}},
+		{"ref", map[string]interface{}{
+			"$defs": map[string]interface{}{"id": map[string]interface{}{"type": "integer", "exclusiveMinimum": 0}},
+			"type":  "array", "items": map[string]interface{}{"$ref": "#/$defs/id"},
+		}, []interface{}{1, 0}, []string{"/1 exclusiveMinimum"}},
+		{"oneOf", map[string]interface{}{"oneOf": []interface{}{
+			map[string]interface{}{"type": "number"}, map[string]interface{}{"type": "integer"},
+		}}, 1, []string{" oneOf"}},
+		{"anyOf not", map[string]interface{}{
+			"anyOf": []interface{}{map[string]interface{}{"type": "string"}, map[string]interface{}{"type": "boolean"}},
+			"not":   map[string]interface{}{"const": "x"},
+		}, "x", []string{" not"}},
+		{"escaped path", map[string]interface{}{"properties": map[string]interface{}{"a/b": map[string]interface{}{"type": "string"}}},
+			map[string]interface{}{"a/b": 1}, []string{"/a~1b type"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := JSON.ValidateSchema(tt.data, tt.schema)
+			var got []string
+			if err != nil {
+				var schemaErrs SchemaErrors
+				if !errors.As(err, &schemaErrs) {
+					t.Fatalf("err = %v, want SchemaErrors", err)
+				}
+				for _, e := range schemaErrs {
+					got = append(got, e.Path+" "+e.Keyword)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("errors = %q, want %q (%v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestParseParamsWithSchema(t *testing.T) {
+	type options struct {
+		Retry   int     `json:"retry" default:"3" min:"0" max:"10"`
+		Verbose bool    `json:"verbose" default:"true"`
+		Ratio   float64 `json:"ratio" default:"0.5"`
+	}
+	type params struct {
+		Name    string   `json:"name" required:"true" min:"1"`
+		Mode    string   `json:"mode" enum:"fast,safe" default:"safe"`
+		Options options  `json:"options"`
+		Limit   *int     `json:"limit" default:"10"`
+		Tags    []string `json:"tags" max:"2"`
+	}
+	tests := []struct {
+		name     string
+		action   map[string]interface{}
+		defaults map[string]interface{}
+		want     []string
+	}{
+		{"defaults", map[string]interface{}{"name": "a", "options": map[string]interface{}{}}, nil, nil},
+		{"missing required", map[string]interface{}{}, nil, []string{"/name required"}},
+		{"enum and range", map[string]interface{}{"name": "a", "mode": "slow", "options": map[string]interface{}{"retry": 11}}, nil,
+			[]string{"/mode enum", "/options/retry maximum"}},
+		{"type", map[string]interface{}{"name": "a", "limit": "x", "tags": []interface{}{"a", 1}}, nil,
+			[]string{"/limit type", "/tags/1 type"}},
+		{"empty string is missing", map[string]interface{}{"name": "a", "mode": "", "options": map[string]interface{}{}}, nil, nil},
+		{"default params", nil, map[string]interface{}{"name": "d", "options": map[string]interface{}{"retry": nil}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p params
+			err := JSON.ParseParamsWithSchema(&p, tt.action, tt.defaults, nil)
+			var got []string
+			if err != nil {
+				var schemaErrs SchemaErrors
+				if !errors.As(err, &schemaErrs) {
+					t.Fatalf("err = %v, want SchemaErrors", err)
+				}
+				for _, e := range schemaErrs {
+					got = append(got, e.Path+" "+e.Keyword)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("errors = %q, want %q (%v)", got, tt.want, err)
+			}
+			if err == nil && (p.Mode != "safe" || p.Limit == nil || *p.Limit != 10 || p.Options.Retry != 3 || !p.Options.Verbose || p.Options.Ratio != 0.5) {
+				t.Fatalf("defaults not applied: %+v", p)
+			}
+		})
+	}
+}
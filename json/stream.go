@@ -0,0 +1,445 @@
+package json
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 流式解析的标记类型
+const (
+	TokenValue       = "value" // 字符串、数字、布尔或 null
+	TokenBeginObject = "{"
+	TokenEndObject   = "}"
+	TokenBeginArray  = "["
+	TokenEndArray    = "]"
+)
+
+// StreamToken 流式解析得到的标记
+type StreamToken struct {
+	Kind  string      // TokenValue、TokenBeginObject 等
+	Path  string      // 值所在的路径，如 [0].children[2].Name，根节点为 ""
+	Key   string      // 对象中的属性名，数组元素为 ""
+	Index int         // 数组中的下标，对象属性为 -1
+	Value interface{} // TokenValue 时的值
+}
+
+// Decoder 从 io.Reader 逐个读取标记，内存占用与数据的嵌套深度相关，而不是数据大小
+type Decoder struct {
+	dec   *json.Decoder
+	stack []*streamFrame
+}
+
+type streamFrame struct {
+	array     bool
+	key       string
+	index     int
+	expectKey bool
+}
+
+// 创建流式解析器
+func (p *jsonStruct) NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{dec: json.NewDecoder(bufio.NewReader(r))}
+}
+
+// 数字解析为 json.Number，需在读取前调用
+func (d *Decoder) UseNumber() *Decoder {
+	d.dec.UseNumber()
+	return d
+}
+
+// 读取下一个标记，数据结束时返回 io.EOF
+func (d *Decoder) Next() (*StreamToken, error) {
+	tok, err := d.dec.Token()
+	if err == io.EOF && len(d.stack) > 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	// 对象中的属性名
+	if top := d.top(); top != nil && !top.array && top.expectKey {
+		if delim, ok := tok.(json.Delim); !ok || delim != '}' {
+			key, ok := tok.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid json object key %v", tok)
+			}
+			top.key, top.expectKey = key, false
+			if tok, err = d.dec.Token(); err != nil {
+				return nil, unexpectedEOF(err)
+			}
+		}
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{', '[':
+			token := d.token(string(t), nil)
+			d.stack = append(d.stack, &streamFrame{array: t == '[', expectKey: t == '{'})
+			return token, nil
+		default:
+			d.stack = d.stack[:len(d.stack)-1]
+			token := d.token(string(t), nil)
+			d.advance()
+			return token, nil
+		}
+	}
+	token := d.token(TokenValue, tok)
+	d.advance()
+	return token, nil
+}
+
+func (d *Decoder) top() *streamFrame {
+	if len(d.stack) == 0 {
+		return nil
+	}
+	return d.stack[len(d.stack)-1]
+}
+
+// 当前值结束，移动到下一个属性或元素
+func (d *Decoder) advance() {
+	if top := d.top(); top != nil {
+		if top.array {
+			top.index++
+		} else {
+			top.expectKey = true
+		}
+	}
+}
+
+func (d *Decoder) token(kind string, value interface{}) *StreamToken {
+	token := &StreamToken{Kind: kind, Path: d.path(), Index: -1, Value: value}
+	if top := d.top(); top != nil {
+		if top.array {
+			token.Index = top.index
+		} else {
+			token.Key = top.key
+		}
+	}
+	return token
+}
+
+// 当前的路径，属性名包含特殊字符时使用 ["key"]
+func (d *Decoder) path() string {
+	var builder strings.Builder
+	for _, frame := range d.stack {
+		switch {
+		case frame.array:
+			builder.WriteString("[" + strconv.Itoa(frame.index) + "]")
+		case strings.ContainsAny(frame.key, ".[]\""):
+			builder.WriteString("[" + strconv.Quote(frame.key) + "]")
+		default:
+			if builder.Len() > 0 {
+				builder.WriteByte('.')
+			}
+			builder.WriteString(frame.key)
+		}
+	}
+	return builder.String()
+}
+
+// 读取 token 对应的完整值，token 为对象或数组的开始时读取到对应的结束
+func (d *Decoder) ReadValue(token *StreamToken) (interface{}, error) {
+	switch token.Kind {
+	case TokenBeginObject:
+		result := map[string]interface{}{}
+		for {
+			child, err := d.Next()
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			if child.Kind == TokenEndObject {
+				return result, nil
+			}
+			value, err := d.ReadValue(child)
+			if err != nil {
+				return nil, err
+			}
+			result[child.Key] = value
+		}
+	case TokenBeginArray:
+		result := []interface{}{}
+		for {
+			child, err := d.Next()
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			if child.Kind == TokenEndArray {
+				return result, nil
+			}
+			value, err := d.ReadValue(child)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+		}
+	case TokenValue:
+		return token.Value, nil
+	}
+	return nil, errors.New("unexpected json token " + token.Kind)
+}
+
+// 跳过 token 对应的值，不保存内容
+func (d *Decoder) Skip(token *StreamToken) error {
+	if token.Kind != TokenBeginObject && token.Kind != TokenBeginArray {
+		return nil
+	}
+	for depth := 1; depth > 0; {
+		child, err := d.Next()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		switch child.Kind {
+		case TokenBeginObject, TokenBeginArray:
+			depth++
+		case TokenEndObject, TokenEndArray:
+			depth--
+		}
+	}
+	return nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// 流式读取数据，对匹配 xpath 的每个值调用 fn，fn 返回 false 时停止读取
+// xpath 支持 name、["key"]、[n]，* 匹配任意属性或元素，[*] 匹配任意元素，..name 匹配任意层级，
+// 如 [*].children[*].Name、..Code；匹配的值内部不会再次匹配
+func (p *jsonStruct) Extract(r io.Reader, xpath string, fn func(path string, value interface{}) bool) error {
+	pattern, err := parseStreamPattern(xpath)
+	if err != nil {
+		return err
+	}
+	decoder := p.NewDecoder(r)
+	for {
+		token, err := decoder.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if token.Kind == TokenEndObject || token.Kind == TokenEndArray {
+			continue
+		}
+		if !matchStreamPath(pattern, decoder.segments(token)) {
+			continue
+		}
+		value, err := decoder.ReadValue(token)
+		if err != nil {
+			return err
+		}
+		if !fn(token.Path, value) {
+			return nil
+		}
+	}
+}
+
+// 路径的每一段，属性为 string，下标为 int
+func (d *Decoder) segments(token *StreamToken) []interface{} {
+	// 对象和数组的开始标记已入栈，不包含自身
+	frames := d.stack
+	if token.Kind == TokenBeginObject || token.Kind == TokenBeginArray {
+		frames = frames[:len(frames)-1]
+	}
+	result := make([]interface{}, len(frames))
+	for i, frame := range frames {
+		if frame.array {
+			result[i] = frame.index
+		} else {
+			result[i] = frame.key
+		}
+	}
+	return result
+}
+
+type streamPatternSegment struct {
+	key       string
+	index     int
+	isKey     bool
+	any       bool // * 或 [*]
+	anyIndex  bool // [*] 只匹配数组元素
+	recursive bool // ..
+}
+
+func parseStreamPattern(xpath string) ([]streamPatternSegment, error) {
+	xpath = strings.TrimSpace(xpath)
+	xpath = strings.TrimPrefix(xpath, "$")
+	var segments []streamPatternSegment
+	recursive := false
+	for i := 0; i < len(xpath); {
+		switch {
+		case strings.HasPrefix(xpath[i:], ".."):
+			recursive = true
+			i += 2
+		case xpath[i] == '.':
+			i++
+		case xpath[i] == '[':
+			end := strings.IndexByte(xpath[i:], ']')
+			if end < 0 {
+				return nil, errors.New("invalid xpath " + xpath + ": missing ]")
+			}
+			inner := strings.TrimSpace(xpath[i+1 : i+end])
+			i += end + 1
+			segment := streamPatternSegment{recursive: recursive}
+			switch {
+			case inner == "*":
+				segment.any, segment.anyIndex = true, true
+			case strings.HasPrefix(inner, "\"") || strings.HasPrefix(inner, "'"):
+				segment.key, segment.isKey = strings.Trim(inner, "\"'"), true
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, errors.New("invalid xpath " + xpath + ": bad index " + inner)
+				}
+				segment.index = index
+			}
+			segments = append(segments, segment)
+			recursive = false
+		default:
+			end := strings.IndexAny(xpath[i:], ".[")
+			if end < 0 {
+				end = len(xpath) - i
+			}
+			name := xpath[i : i+end]
+			segments = append(segments, streamPatternSegment{key: name, isKey: true, any: name == "*", recursive: recursive})
+			recursive = false
+			i += end
+		}
+	}
+	return segments, nil
+}
+
+func matchStreamPath(pattern []streamPatternSegment, path []interface{}) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	segment := pattern[0]
+	if segment.recursive {
+		for i := range path {
+			if matchStreamSegment(segment, path[i]) && matchStreamPath(pattern[1:], path[i+1:]) {
+				return true
+			}
+		}
+		return false
+	}
+	return len(path) > 0 && matchStreamSegment(segment, path[0]) && matchStreamPath(pattern[1:], path[1:])
+}
+
+func matchStreamSegment(segment streamPatternSegment, element interface{}) bool {
+	switch e := element.(type) {
+	case string:
+		return (segment.any && !segment.anyIndex) || (segment.isKey && segment.key == e)
+	case int:
+		return segment.any || (!segment.isKey && segment.index == e)
+	}
+	return false
+}
+
+// Encoder 将数据逐层写入 io.Writer，不在内存中生成完整的字符串
+type Encoder struct {
+	w *bufio.Writer
+}
+
+// 创建流式编码器
+func (p *jsonStruct) NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w)}
+}
+
+// 写入一个值并换行，对象的键按顺序输出，与 Stringify 的结果一致
+func (e *Encoder) Encode(value interface{}) error {
+	if err := e.encode(value); err != nil {
+		return err
+	}
+	if err := e.w.WriteByte('\n'); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+func (e *Encoder) encode(value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return e.encodeObject(keys, func(key string) interface{} { return v[key] })
+	case map[interface{}]interface{}:
+		return e.encode(convertMap(v))
+	case *sync.Map:
+		m := map[string]interface{}{}
+		v.Range(func(key, item interface{}) bool {
+			m[fmt.Sprint(key)] = item
+			return true
+		})
+		return e.encode(m)
+	case []interface{}:
+		if err := e.w.WriteByte('['); err != nil {
+			return err
+		}
+		for i, item := range v {
+			if i > 0 {
+				if err := e.w.WriteByte(','); err != nil {
+					return err
+				}
+			}
+			if err := e.encode(item); err != nil {
+				return err
+			}
+		}
+		return e.w.WriteByte(']')
+	}
+
+	// 其他类型的数组按元素写入，其余类型交给 encoding/json
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 && !rv.IsNil() {
+		items := make([]interface{}, rv.Len())
+		for i := range items {
+			items[i] = rv.Index(i).Interface()
+		}
+		return e.encode(items)
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(b)
+	return err
+}
+
+func (e *Encoder) encodeObject(keys []string, get func(key string) interface{}) error {
+	if err := e.w.WriteByte('{'); err != nil {
+		return err
+	}
+	for i, key := range keys {
+		if i > 0 {
+			if err := e.w.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		b, _ := json.Marshal(key)
+		if _, err := e.w.Write(b); err != nil {
+			return err
+		}
+		if err := e.w.WriteByte(':'); err != nil {
+			return err
+		}
+		if err := e.encode(get(key)); err != nil {
+			return err
+		}
+	}
+	return e.w.WriteByte('}')
+}
@@ -0,0 +1,209 @@
+package json
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// 逐个写入 n 个元素的数组，不在内存中生成完整的数据
+func largeArrayReader(n int) io.Reader {
+	r, w := io.Pipe()
+	go func() {
+		w.Write([]byte("["))
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				w.Write([]byte(","))
+			}
+			fmt.Fprintf(w, `{"id":%d,"tags":["t%d"],"meta":{"skip":[1,2,3]}}`, i, i)
+		}
+		w.Write([]byte("]"))
+		w.Close()
+	}()
+	return r
+}
+
+func TestDecoderLargeArray(t *testing.T) {
+	const n = 100000
+	decoder := JSON.NewDecoder(largeArrayReader(n)).UseNumber()
+	token, err := decoder.Next()
+	if err != nil || token.Kind != TokenBeginArray || token.Path != "" {
+		t.Fatalf("first token = %+v, %v", token, err)
+	}
+	count := 0
+	for {
+		token, err = decoder.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.Kind == TokenEndArray {
+			break
+		}
+		if token.Kind != TokenBeginObject || token.Index != count || token.Path != fmt.Sprintf("[%d]", count) {
+			t.Fatalf("element token = %+v, want object at [%d]", token, count)
+		}
+		value, err := decoder.ReadValue(token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		item := value.(map[string]interface{})
+		if item["id"] != stdjson.Number(fmt.Sprint(count)) || item["tags"].([]interface{})[0] != fmt.Sprintf("t%d", count) {
+			t.Fatalf("element %d = %#v", count, item)
+		}
+		count++
+	}
+	if count != n {
+		t.Fatalf("read %d elements, want %d", count, n)
+	}
+	if _, err = decoder.Next(); err != io.EOF {
+		t.Fatalf("after end: %v, want io.EOF", err)
+	}
+}
+
+func TestDecoderTokens(t *testing.T) {
+	decoder := JSON.NewDecoder(strings.NewReader(`{"a":[1,{"b.c":null}],"d":{"e":true},"f":"x"}`))
+	var got []string
+	for {
+		token, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.Key == "d" {
+			if err = decoder.Skip(token); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, "skip d")
+			continue
+		}
+		got = append(got, fmt.Sprintf("%s %s %v", token.Kind, token.Path, token.Value))
+	}
+	want := []string{
+		"{  <nil>",
+		"[ a <nil>",
+		"value a[0] 1",
+		"{ a[1] <nil>",
+		`value a[1]["b.c"] <nil>`,
+		"} a[1] <nil>",
+		"] a <nil>",
+		"skip d",
+		"value f x",
+		"}  <nil>",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("tokens = %q, want %q", got, want)
+	}
+
+	if _, err := JSON.NewDecoder(strings.NewReader(`[1,2`)).ReadValue(&StreamToken{Kind: TokenBeginArray}); err == nil {
+		t.Fatal("truncated array returned no error")
+	}
+}
+
+func TestExtract(t *testing.T) {
+	data := `[{"Name":"a","Code":1,"children":[{"Name":"a1","Code":2},{"Name":"a2"}]},{"Name":"b","children":[]}]`
+	tests := []struct {
+		xpath string
+		limit int
+		want  []string
+	}{
+		{"[*].Name", 0, []string{"[0].Name=a", "[1].Name=b"}},
+		{"[*].children[*].Name", 0, []string{"[0].children[0].Name=a1", "[0].children[1].Name=a2"}},
+		{"..Code", 0, []string{"[0].Code=1", "[0].children[0].Code=2"}},
+		{"[0].children[1]", 0, []string{"[0].children[1]=map[Name:a2]"}},
+		{"[*]", 1, []string{"[0]=map[Code:1 Name:a children:[map[Code:2 Name:a1] map[Name:a2]]]"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		err := JSON.Extract(strings.NewReader(data), tt.xpath, func(path string, value interface{}) bool {
+			got = append(got, fmt.Sprintf("%s=%v", path, value))
+			return tt.limit == 0 || len(got) < tt.limit
+		})
+		if err != nil {
+			t.Fatalf("Extract(%s): %v", tt.xpath, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("Extract(%s) = %q, want %q", tt.xpath, got, tt.want)
+		}
+	}
+}
+
+func TestEncoderMatchesStringify(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+	values := []interface{}{
+		map[string]interface{}{"b": 1.5, "a": []interface{}{"x", nil, true}, "c": map[string]interface{}{"z": "<&>", "y": "引号\""}},
+		[]interface{}{map[string]interface{}{"k": int64(1)}, []interface{}{}},
+		[]item{{Name: "a"}, {Name: "b"}},
+		map[string]interface{}{"items": []map[string]interface{}{{"id": 1}}, "n": stdjson.Number("12345678901234567890")},
+	}
+	for _, value := range values {
+		var buf bytes.Buffer
+		if err := JSON.NewEncoder(&buf).Encode(value); err != nil {
+			t.Fatal(err)
+		}
+		if want := JSON.Stringify(value) + "\n"; buf.String() != want {
+			t.Fatalf("Encode = %q, Stringify = %q", buf.String(), want)
+		}
+		// 编码结果可以再次流式解析
+		decoder := JSON.NewDecoder(&buf).UseNumber()
+		token, err := decoder.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = decoder.ReadValue(token); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCloneIndependent(t *testing.T) {
+	type node struct {
+		Name     string
+		Tags     []string
+		Next     *node
+		Children map[string]*node
+	}
+	original := map[string]interface{}{
+		"list": []interface{}{map[string]interface{}{"a": 1.0}},
+		"tags": []string{"x"},
+		"tree": &node{Name: "root", Tags: []string{"t"}, Children: map[string]*node{"c": {Name: "child"}}},
+	}
+	sm := &sync.Map{}
+	sm.Store("k", map[string]interface{}{"v": 1})
+	original["sync"] = sm
+	root := original["tree"].(*node)
+	root.Next = root // 循环引用
+
+	cloned := JSON.Clone(original).(map[string]interface{})
+	cloned["list"].([]interface{})[0].(map[string]interface{})["a"] = 2.0
+	cloned["tags"].([]string)[0] = "changed"
+	tree := cloned["tree"].(*node)
+	tree.Tags[0] = "changed"
+	tree.Children["c"].Name = "changed"
+	loaded, _ := cloned["sync"].(*sync.Map).Load("k")
+	loaded.(map[string]interface{})["v"] = 2
+
+	if original["list"].([]interface{})[0].(map[string]interface{})["a"] != 1.0 || original["tags"].([]string)[0] != "x" {
+		t.Fatalf("clone shares maps or slices with the original: %#v", original)
+	}
+	if root.Tags[0] != "t" || root.Children["c"].Name != "child" {
+		t.Fatalf("clone shares struct fields with the original: %+v", root)
+	}
+	if v, _ := sm.Load("k"); v.(map[string]interface{})["v"] != 1 {
+		t.Fatal("clone shares sync.Map values with the original")
+	}
+	if tree == root || tree.Next != tree {
+		t.Fatal("cyclic pointer not cloned once")
+	}
+	if JSON.Clone("s") != "s" || JSON.Clone(nil) != nil {
+		t.Fatal("Clone changed a primitive value")
+	}
+}
@@ -0,0 +1,372 @@
+package json
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 模板的转义方式，根据模板所在的上下文选择
+const (
+	EscapeNone = ""     // 原样输出
+	EscapeJSON = "json" // 模板为JSON文本，字符串按JSON字符串内容转义
+	EscapeSQL  = "sql"  // 模板为SQL，值输出为标准SQL字面量：字符串加单引号并将 ' 转义为 ''，null输出NULL
+	EscapeURL  = "url"  // 模板为URL，值按查询参数转义
+	EscapeHTML = "html" // 模板为HTML，值按HTML转义
+)
+
+// MissingVariableError mustHas 时缺少变量的错误，Paths 为所有缺少的路径
+type MissingVariableError struct {
+	Paths []string
+}
+
+func (e *MissingVariableError) Error() string {
+	return "missing variables: " + strings.Join(e.Paths, ", ")
+}
+
+// 渲染模板，支持两种写法：
+//   - $Var.path、$Var["key"].path：变量名以大写字母或$开头，按最长匹配识别，$A 不会替换 $AB 的前缀；
+//     路径可以包含汉字、字母、数字、_ 和 -，如 $Data.名称、$Data.a-b
+//   - ${Var.path | default:'x' | upper | json}：path 之后可以接多个过滤器
+//
+// 过滤器：default:'值'、upper、lower、trim、join:'分隔符'、json（输出JSON文本，仍按 escape 转义）、raw（不转义，只有 raw 可以跳过转义）
+// escape 为 EscapeNone、EscapeJSON、EscapeSQL、EscapeURL 或 EscapeHTML
+// mustHas 为 true 时，缺少变量（且没有 default）返回 *MissingVariableError，列出所有缺少的路径；否则替换为空字符串
+func (p *jsonStruct) Render(template string, data interface{}, escape string, mustHas bool) (string, error) {
+	return render(template, data, escape, mustHas, true)
+}
+
+// braces 为 false 时只识别 $Var.path，${...} 原样保留，兼容 ReplaceXPathValue 的原有规则（如 JS 模板字符串中的 ${name}）
+func render(template string, data interface{}, escape string, mustHas, braces bool) (string, error) {
+	parts, err := parseTemplate(template, braces)
+	if err != nil {
+		return "", err
+	}
+	switch escape {
+	case EscapeNone, EscapeJSON, EscapeSQL, EscapeURL, EscapeHTML:
+	default:
+		return "", errors.New("unknown escape mode: " + escape)
+	}
+
+	var builder strings.Builder
+	var missing []string
+	for _, part := range parts {
+		if part.expr == nil {
+			builder.WriteString(part.text)
+			continue
+		}
+		text, found, err := part.expr.render(data, escape)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			missing = append(missing, part.expr.path)
+		}
+		builder.WriteString(text)
+	}
+	if mustHas && len(missing) > 0 {
+		return "", &MissingVariableError{Paths: missing}
+	}
+	return builder.String(), nil
+}
+
+type templatePart struct {
+	text string
+	expr *templateExpr
+}
+
+type templateExpr struct {
+	path    string
+	filters []templateFilter
+}
+
+type templateFilter struct {
+	name string
+	args []string
+}
+
+// 拆分模板为文本和变量，braces 为 false 时 ${...} 作为普通文本
+func parseTemplate(template string, braces bool) ([]templatePart, error) {
+	var parts []templatePart
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			parts = append(parts, templatePart{text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(template); {
+		if template[i] != '$' {
+			text.WriteByte(template[i])
+			i++
+			continue
+		}
+		if braces && strings.HasPrefix(template[i:], "${") {
+			end, err := findTemplateEnd(template, i+2)
+			var expr *templateExpr
+			if err == nil {
+				expr, err = parseTemplateExpr(template[i+2 : end])
+			}
+			if err != nil {
+				return nil, err
+			}
+			flush()
+			parts = append(parts, templatePart{expr: expr})
+			i = end + 1
+			continue
+		}
+		if end := scanBareVariable(template, i+1); end > i+1 {
+			flush()
+			parts = append(parts, templatePart{expr: &templateExpr{path: template[i+1 : end]}})
+			i = end
+			continue
+		}
+		text.WriteByte('$')
+		i++
+	}
+	flush()
+	return parts, nil
+}
+
+// 查找 ${ 对应的 }，忽略引号中的字符
+func findTemplateEnd(template string, start int) (int, error) {
+	var quote byte
+	for i := start; i < len(template); i++ {
+		c := template[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '}':
+			return i, nil
+		}
+	}
+	return 0, errors.New("unclosed ${ in template at " + strconv.Itoa(start-2))
+}
+
+// 变量路径中的字符：汉字、字母、数字、_ 和 -，与旧版 ReplaceXPathValue 的匹配规则一致
+func templateNameCharLen(template string, i int) int {
+	if i >= len(template) {
+		return 0
+	}
+	c := template[i]
+	if c == '_' || c == '-' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+		return 1
+	}
+	if c < utf8.RuneSelf {
+		return 0
+	}
+	r, size := utf8.DecodeRuneInString(template[i:])
+	if unicode.Is(unicode.Han, r) {
+		return size
+	}
+	return 0
+}
+
+// 跳过连续的路径字符
+func skipTemplateName(template string, i int) int {
+	for {
+		size := templateNameCharLen(template, i)
+		if size == 0 {
+			return i
+		}
+		i += size
+	}
+}
+
+// 扫描 $ 之后的变量路径，返回结束位置，不是变量时返回 start
+// 变量名以大写字母或$开头，之后可以接 .name、[n]、["key"]，末尾的 . 不属于变量
+func scanBareVariable(template string, start int) int {
+	if start >= len(template) {
+		return start
+	}
+	if c := template[start]; !(c >= 'A' && c <= 'Z') && c != '$' {
+		return start
+	}
+	i := skipTemplateName(template, start+1)
+	for i < len(template) {
+		switch template[i] {
+		case '.':
+			if templateNameCharLen(template, i+1) > 0 {
+				i = skipTemplateName(template, i+1)
+				continue
+			}
+		case '[':
+			end := strings.IndexByte(template[i:], ']')
+			if end > 1 {
+				inner := template[i+1 : i+end]
+				if _, err := strconv.Atoi(inner); err == nil ||
+					(len(inner) >= 2 && inner[0] == '"' && inner[len(inner)-1] == '"') {
+					i += end + 1
+					continue
+				}
+			}
+		}
+		break
+	}
+	return i
+}
+
+// 解析 path | filter:arg:arg | filter
+func parseTemplateExpr(source string) (*templateExpr, error) {
+	segments := splitTemplateExpr(source, '|')
+	expr := &templateExpr{path: strings.TrimSpace(segments[0])}
+	if expr.path == "" {
+		return nil, errors.New("empty variable in template: ${" + source + "}")
+	}
+	for _, segment := range segments[1:] {
+		items := splitTemplateExpr(segment, ':')
+		filter := templateFilter{name: strings.TrimSpace(items[0])}
+		for _, arg := range items[1:] {
+			arg = strings.TrimSpace(arg)
+			if len(arg) >= 2 && (arg[0] == '\'' || arg[0] == '"') && arg[len(arg)-1] == arg[0] {
+				arg = strings.ReplaceAll(arg[1:len(arg)-1], "\\"+string(arg[0]), string(arg[0]))
+			}
+			filter.args = append(filter.args, arg)
+		}
+		switch filter.name {
+		case "default", "join":
+			if len(filter.args) != 1 {
+				return nil, fmt.Errorf("filter %s requires 1 argument in template: ${%s}", filter.name, source)
+			}
+		case "upper", "lower", "trim", "json", "raw":
+		default:
+			return nil, fmt.Errorf("unknown filter %s in template: ${%s}", filter.name, source)
+		}
+		expr.filters = append(expr.filters, filter)
+	}
+	return expr, nil
+}
+
+// 按分隔符拆分，忽略引号中的分隔符
+func splitTemplateExpr(source string, sep byte) []string {
+	var result []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(source); i++ {
+		c := source[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == sep:
+			result = append(result, source[start:i])
+			start = i + 1
+		}
+	}
+	return append(result, source[start:])
+}
+
+// 渲染变量，返回文本和变量是否存在（有 default 时视为存在）
+func (e *templateExpr) render(data interface{}, escape string) (string, bool, error) {
+	value, found := JSON.GetXPathValue(data, e.path)
+	raw := false
+	for _, filter := range e.filters {
+		switch filter.name {
+		case "default":
+			if !found || value == nil || value == "" {
+				value, found = filter.args[0], true
+			}
+		case "upper":
+			value = strings.ToUpper(templateString(value))
+		case "lower":
+			value = strings.ToLower(templateString(value))
+		case "trim":
+			value = strings.TrimSpace(templateString(value))
+		case "join":
+			if list, ok := value.([]interface{}); ok {
+				items := make([]string, len(list))
+				for i, item := range list {
+					items[i] = templateString(item)
+				}
+				value = strings.Join(items, filter.args[0])
+			}
+		case "json":
+			b, err := json.Marshal(convertValue(value))
+			if err != nil {
+				return "", found, fmt.Errorf("%s: %w", e.path, err)
+			}
+			value = string(b)
+		case "raw":
+			raw = true
+		}
+	}
+	if !found {
+		return "", false, nil
+	}
+	if raw {
+		return templateString(value), true, nil
+	}
+	return escapeTemplateValue(value, escape), true, nil
+}
+
+// 值转换为文本，对象和数组输出JSON，null输出 null
+func templateString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return "null"
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(convertValue(v))
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	}
+	return fmt.Sprint(value)
+}
+
+func escapeTemplateValue(value interface{}, escape string) string {
+	switch escape {
+	case EscapeJSON:
+		if s, ok := value.(string); ok {
+			b, _ := json.Marshal(s)
+			return string(b[1 : len(b)-1])
+		}
+		return templateString(value)
+	case EscapeSQL:
+		switch v := value.(type) {
+		case nil:
+			return "NULL"
+		case bool:
+			if v {
+				return "TRUE"
+			}
+			return "FALSE"
+		case string:
+			// 按标准 SQL 只转义单引号，反斜杠原样保留；MySQL 需要开启 NO_BACKSLASH_ESCAPES
+			return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+		}
+		if _, ok := toFloat(value); ok {
+			return templateString(value)
+		}
+		return "'" + strings.ReplaceAll(templateString(value), "'", "''") + "'"
+	case EscapeURL:
+		return url.QueryEscape(templateString(value))
+	case EscapeHTML:
+		return html.EscapeString(templateString(value))
+	}
+	return templateString(value)
+}
@@ -0,0 +1,88 @@
+package json
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReplaceXPathValue(t *testing.T) {
+	data := map[string]interface{}{
+		"Data": map[string]interface{}{"名称": "x", "a-b": "y", "n": 1.0, "list": []interface{}{"a", "b"}},
+		"A":    "short",
+		"AB":   "long",
+	}
+	tests := []struct {
+		name     string
+		template string
+		mustHas  bool
+		want     string
+		wantOK   bool
+	}{
+		{"han key", "v=$Data.名称", false, "v=x", true},
+		{"dash key", "v=$Data.a-b", false, "v=y", true},
+		{"number", "n=$Data.n", false, "n=1", true},
+		{"index", "$Data.list[1]", false, "b", true},
+		{"longest name", "$A $AB", false, "short long", true},
+		{"trailing dot", "$A.", false, "short.", true},
+		{"braces kept", "${Data.名称 | upper}", false, "${Data.名称 | upper}", true},
+		{"js template literal", "`hi ${name}` + $A", true, "`hi ${name}` + short", true},
+		{"missing", "v=$Missing", false, "v=", true},
+		{"missing required", "v=$Missing", true, "", false},
+		{"unclosed brace kept", "cost ${A", false, "cost ${A", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := JSON.ReplaceXPathValue(data, tt.template, tt.mustHas)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("ReplaceXPathValue(%q) = %q, %v, want %q, %v", tt.template, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	data := map[string]interface{}{"Name": "O'Reilly & <b>", "Quote": "x'y", "Path": `C:\dir\`, "Tags": []interface{}{"a", "b"}, "Empty": nil}
+	tests := []struct {
+		name     string
+		template string
+		escape   string
+		want     string
+	}{
+		{"none", "$Name", EscapeNone, "O'Reilly & <b>"},
+		{"sql", "name = $Name", EscapeSQL, "name = 'O''Reilly & <b>'"},
+		{"sql null", "v = $Empty", EscapeSQL, "v = NULL"},
+		{"sql backslash", "v = $Path", EscapeSQL, `v = 'C:\dir\'`},
+		{"html", "<p>$Name</p>", EscapeHTML, "<p>O&#39;Reilly &amp; &lt;b&gt;</p>"},
+		{"url", "q=$Name", EscapeURL, "q=O%27Reilly+%26+%3Cb%3E"},
+		{"json", `{"n":"$Name"}`, EscapeJSON, `{"n":"O'Reilly \u0026 \u003cb\u003e"}`},
+		{"join", "${Tags | join:'-'}", EscapeNone, "a-b"},
+		{"raw", "${Name | raw}", EscapeHTML, "O'Reilly & <b>"},
+		{"json filter sql", "v = ${Quote | json}", EscapeSQL, `v = '"x''y"'`},
+		{"json filter html", "<p>${Tags | json}</p>", EscapeHTML, "<p>[&#34;a&#34;,&#34;b&#34;]</p>"},
+		{"json filter raw", "${Tags | json | raw}", EscapeHTML, `["a","b"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSON.Render(tt.template, data, tt.escape, false)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Render(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	_, err := JSON.Render("$A $B.c", map[string]interface{}{}, EscapeNone, true)
+	var missing *MissingVariableError
+	if !errors.As(err, &missing) || len(missing.Paths) != 2 {
+		t.Fatalf("err = %v, want MissingVariableError with 2 paths", err)
+	}
+	for _, template := range []string{"${A", "${A | nope}", "${}"} {
+		if _, err := JSON.Render(template, nil, EscapeNone, false); err == nil {
+			t.Fatalf("Render(%q) returned no error", template)
+		}
+	}
+}
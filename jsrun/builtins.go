@@ -0,0 +1,102 @@
+package jsrun
+
+import (
+	"github.com/dop251/goja"
+)
+
+// 冻结内置对象、构造函数及其原型（全局对象除外，由 reset 复位），脚本无法修改 Array.prototype.map、JSON.stringify 等，
+// 避免一次执行的修改影响同一运行时上后续不相关的脚本。
+// 冻结后在严格模式下给继承这些属性的对象赋值（如 err.name = "x"、obj.toString = fn）会抛出 TypeError，
+// 因此常被覆盖的属性改为访问器：读取返回原值，在其他对象上赋值时为该对象定义自有属性，在内置对象本身上赋值时抛出 TypeError
+var hardenBuiltinsProg = goja.MustCompile("hardenBuiltins.js", `(function (global, roots) {
+	var ownKeys = Reflect.ownKeys, getDesc = Object.getOwnPropertyDescriptor, getProto = Object.getPrototypeOf,
+		defineProperty = Object.defineProperty, freeze = Object.freeze;
+	var overridable = ["constructor", "toString", "toLocaleString", "valueOf", "hasOwnProperty", "isPrototypeOf",
+		"propertyIsEnumerable", "name", "message", "push", "concat", "bind", "apply", "call", "then", "toJSON"];
+
+	function enableOverride(obj, key, desc) {
+		var value = desc.value;
+		defineProperty(obj, key, {
+			get: function () { return value; },
+			set: function (newValue) {
+				if (this === obj) {
+					throw new TypeError("Cannot assign to read only property '" + String(key) + "' of builtin object");
+				}
+				defineProperty(this, key, { value: newValue, writable: true, enumerable: true, configurable: true });
+			},
+			enumerable: desc.enumerable,
+			configurable: false
+		});
+	}
+
+	var seen = new Set([global]), queue = [];
+	function add(v) {
+		if ((typeof v === "object" || typeof v === "function") && v !== null && !seen.has(v)) {
+			seen.add(v);
+			queue.push(v);
+		}
+	}
+	roots.forEach(add);
+	[
+		[][Symbol.iterator](), new Map()[Symbol.iterator](), new Set()[Symbol.iterator](),
+		""[Symbol.iterator](), /x/[Symbol.matchAll](""), function* () {}, function* () {}.prototype,
+		async function () {}, Int8Array
+	].forEach(function (v) { add(getProto(v)); });
+	for (var i = 0; i < queue.length; i++) {
+		add(getProto(queue[i]));
+		var desc = getDesc(queue[i], "prototype");
+		if (desc) {
+			add(desc.value);
+		}
+	}
+	queue.forEach(function (obj) {
+		ownKeys(obj).forEach(function (key) {
+			var desc = getDesc(obj, key);
+			if (desc.writable && desc.configurable && overridable.indexOf(key) >= 0) {
+				enableOverride(obj, key, desc);
+			}
+		});
+		freeze(obj);
+	});
+})`, true)
+
+// 禁止从字符串生成代码：删除 eval，Function 以及各类函数原型上的 constructor 替换为调用即抛出 TypeError 的函数，
+// 避免通过 (() => {}).constructor、globalThis["Function"] 等方式绕过静态检查；替换后的 Function 保留原型，instanceof Function 仍然可用
+var disableCodeGenerationProg = goja.MustCompile("disableCodeGeneration.js", `(function (global) {
+	var getProto = Object.getPrototypeOf, defineProperty = Object.defineProperty;
+	var blocked = function Function() {
+		throw new TypeError("Code generation from strings is not allowed");
+	};
+	defineProperty(blocked, "prototype", { value: Function.prototype });
+	[Function.prototype, getProto(function* () {}), getProto(async function () {})].forEach(function (proto) {
+		defineProperty(proto, "constructor", { value: blocked, writable: true, configurable: true });
+	});
+	defineProperty(global, "Function", { value: blocked, writable: true, configurable: true });
+	delete global.eval;
+})`, true)
+
+// 禁用 eval 与 Function 构造函数，需要在记录全局变量之前调用
+func disableCodeGeneration(vm *goja.Runtime) error {
+	disable, err := vm.RunProgram(disableCodeGenerationProg)
+	if err != nil {
+		return err
+	}
+	fn, _ := goja.AssertFunction(disable)
+	_, err = fn(goja.Undefined(), vm.GlobalObject())
+	return err
+}
+
+// 冻结内置对象，globals 为初始化完成时的全局变量
+func hardenBuiltins(vm *goja.Runtime, globals map[string]goja.Value) error {
+	harden, err := vm.RunProgram(hardenBuiltinsProg)
+	if err != nil {
+		return err
+	}
+	fn, _ := goja.AssertFunction(harden)
+	roots := make([]interface{}, 0, len(globals))
+	for _, value := range globals {
+		roots = append(roots, value)
+	}
+	_, err = fn(goja.Undefined(), vm.GlobalObject(), vm.NewArray(roots...))
+	return err
+}
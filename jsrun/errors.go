@@ -0,0 +1,194 @@
+package jsrun
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dop251/goja"
+	utils "github.com/skyfox2000/nect-utils"
+)
+
+// Compile 在脚本前注入的包装代码行数
+const wrapperLines = 2
+
+// 错误日志中代码片段的上下文行数
+const excerptLines = 3
+
+// StackFrame 脚本调用栈的一帧，行号已对应到原始脚本
+type StackFrame struct {
+	Func   string
+	File   string
+	Line   int
+	Column int
+}
+
+func (f StackFrame) String() string {
+	if f.File == "" {
+		return f.Func + " (native)"
+	}
+	pos := f.File + ":" + strconv.Itoa(f.Line) + ":" + strconv.Itoa(f.Column)
+	if f.Func != "" {
+		return f.Func + " (" + pos + ")"
+	}
+	return pos
+}
+
+// ScriptError 脚本抛出的异常，作为 CustomError.Data 返回，可用 errors.As 获取
+type ScriptError struct {
+	Name    string       // 异常类型，如 TypeError，抛出非 Error 值时为空
+	Message string       // 异常信息
+	File    string       // 抛出异常的文件
+	Line    int          // 行号，已扣除包装代码
+	Column  int          // 列号
+	Stack   []StackFrame // JS 调用栈，最近的调用在前
+	Value   interface{}  // 抛出的原始值
+}
+
+func (e *ScriptError) Error() string {
+	msg := e.Message
+	if e.Name != "" {
+		msg = e.Name + ": " + e.Message
+	}
+	if e.File != "" {
+		msg += " at " + e.File + ":" + strconv.Itoa(e.Line) + ":" + strconv.Itoa(e.Column)
+	}
+	return msg
+}
+
+// scriptSource 编译时保存的源码，用于修正行号与输出代码片段
+type scriptSource struct {
+	code       string
+	lineOffset int // 包装代码占用的行数
+	colOffset  int // 包装代码在第一行占用的列数
+}
+
+func (p *jsrunStruct) storeSource(file string, source *scriptSource) {
+	p.programMutex.Lock()
+	defer p.programMutex.Unlock()
+	if p.sources == nil {
+		p.sources = make(map[string]*scriptSource)
+	}
+	p.sources[file] = source
+}
+
+func (p *jsrunStruct) loadSource(file string) *scriptSource {
+	p.programMutex.RLock()
+	defer p.programMutex.RUnlock()
+	return p.sources[file]
+}
+
+// 调用栈的一行，如 "at foo (a.js:3:5(12))" 或 "at a.js:3:5(12)"
+var stackLinePattern = regexp.MustCompile(`^\s*at (?:(.*?) \()?(.+?):(\d+):(\d+)\(\d+\)\)?$`)
+var nativeLinePattern = regexp.MustCompile(`^\s*at (?:(.*?) \()?native\)?$`)
+
+// 将 JS 抛出的值转换为 ScriptError
+func (p *jsrunStruct) newScriptError(value goja.Value, stack string) *ScriptError {
+	scriptErr := &ScriptError{}
+	if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+		scriptErr.Message = fmt.Sprint(value)
+	} else {
+		scriptErr.Value = value.Export()
+		scriptErr.Message = value.String()
+		if obj, ok := value.(*goja.Object); ok {
+			if name := obj.Get("name"); name != nil && !goja.IsUndefined(name) {
+				scriptErr.Name = name.String()
+				scriptErr.Message = obj.Get("message").String()
+			}
+			if s := obj.Get("stack"); s != nil && !goja.IsUndefined(s) {
+				stack = s.String()
+			}
+		}
+	}
+
+	for _, line := range strings.Split(stack, "\n") {
+		if m := stackLinePattern.FindStringSubmatch(line); m != nil {
+			frame := StackFrame{Func: m[1], File: m[2]}
+			frame.Line, _ = strconv.Atoi(m[3])
+			frame.Column, _ = strconv.Atoi(m[4])
+			if source := p.loadSource(frame.File); source != nil {
+				if frame.Line == 1 {
+					frame.Column -= source.colOffset
+				}
+				frame.Line -= source.lineOffset
+				// 包装代码自身的调用帧
+				if frame.Line < 1 || frame.Line > strings.Count(source.code, "\n")+1 {
+					continue
+				}
+			}
+			scriptErr.Stack = append(scriptErr.Stack, frame)
+		} else if m := nativeLinePattern.FindStringSubmatch(line); m != nil {
+			scriptErr.Stack = append(scriptErr.Stack, StackFrame{Func: m[1]})
+		}
+	}
+	for _, frame := range scriptErr.Stack {
+		if frame.File != "" {
+			scriptErr.File = frame.File
+			scriptErr.Line = frame.Line
+			scriptErr.Column = frame.Column
+			break
+		}
+	}
+	return scriptErr
+}
+
+// 将脚本异常转换为 ScriptError，其他错误原样返回
+func (p *jsrunStruct) exceptionError(err error) error {
+	var exception *goja.Exception
+	if !errors.As(err, &exception) {
+		return err
+	}
+	// GoError 包装的 Go 错误（如 require 失败）保持原样
+	if inner := exception.Unwrap(); inner != nil {
+		return inner
+	}
+	// Exception.String() 第一行为异常值，之后为调用栈
+	_, stack, _ := strings.Cut(exception.String(), "\n")
+	return p.newScriptError(exception.Value(), stack)
+}
+
+// 脚本异常包装为 CustomError 并输出代码片段
+func (p *jsrunStruct) scriptFailure(scriptErr *ScriptError, utilsTool utils.UtilsTool) *utils.CustomError {
+	p.logScriptError(scriptErr, utilsTool)
+	return &utils.CustomError{
+		Errno: ErrnoScript,
+		Msg:   "[" + utilsTool.Name + "] " + scriptErr.Error(),
+		Data:  scriptErr,
+	}
+}
+
+// 输出异常所在位置前后几行代码，格式与编译错误一致
+func (p *jsrunStruct) logScriptError(scriptErr *ScriptError, utilsTool utils.UtilsTool) {
+	if utilsTool.Logger == nil {
+		return
+	}
+	source := p.loadSource(scriptErr.File)
+	if source == nil || scriptErr.Line <= 0 {
+		utilsTool.Logger.Error("[" + utilsTool.Name + "] error running script: " + scriptErr.Error())
+		return
+	}
+
+	lines := strings.Split(source.code, "\n")
+	start := scriptErr.Line - excerptLines
+	if start < 1 {
+		start = 1
+	}
+	end := scriptErr.Line + excerptLines
+	if end > len(lines) {
+		end = len(lines)
+	}
+	excerpt := make([]string, 0, end-start+2)
+	for index := start; index <= end; index++ {
+		excerpt = append(excerpt, "line "+strconv.Itoa(index)+":  "+lines[index-1])
+		if index == scriptErr.Line {
+			indent := len("line "+strconv.Itoa(index)+":  ") + scriptErr.Column - 1
+			if indent < 0 {
+				indent = 0
+			}
+			excerpt = append(excerpt, strings.Repeat(" ", indent)+"^ "+scriptErr.Error())
+		}
+	}
+	utilsTool.Logger.Error("["+utilsTool.Name+"] error running script: \n", strings.Join(excerpt, "\n"))
+}
@@ -0,0 +1,71 @@
+package jsrun
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	utils "github.com/skyfox2000/nect-utils"
+)
+
+// 行号与列号扣除 Compile 与模块加载注入的包装代码
+func TestScriptErrorPosition(t *testing.T) {
+	code := "var a = 1;\n" +
+		"function fail() {\n" +
+		"  throw new RangeError(\"bad \" + a);\n" +
+		"}\n" +
+		"fail();"
+	_, err := runScript(t, "position", code, nil)
+	if !errors.Is(err, utils.NewError(ErrnoScript, "")) {
+		t.Fatalf("error = %v, want errno %d", err, ErrnoScript)
+	}
+	var scriptErr *ScriptError
+	if !errors.As(err, &scriptErr) {
+		t.Fatalf("error = %#v, want *ScriptError", err)
+	}
+	if scriptErr.Name != "RangeError" || scriptErr.Message != "bad 1" {
+		t.Fatalf("error = %s: %s, want RangeError: bad 1", scriptErr.Name, scriptErr.Message)
+	}
+	wantStack := []StackFrame{
+		{Func: "fail", File: "position.js", Line: 3, Column: 9},
+		{File: "position.js", Line: 5, Column: 5},
+	}
+	if !reflect.DeepEqual(scriptErr.Stack, wantStack) {
+		t.Fatalf("stack = %+v, want %+v", scriptErr.Stack, wantStack)
+	}
+	if scriptErr.File != "position.js" || scriptErr.Line != 3 || scriptErr.Column != 9 {
+		t.Fatalf("position = %s:%d:%d, want position.js:3:9", scriptErr.File, scriptErr.Line, scriptErr.Column)
+	}
+	if want := "RangeError: bad 1 at position.js:3:9"; scriptErr.Error() != want {
+		t.Fatalf("Error() = %q, want %q", scriptErr.Error(), want)
+	}
+}
+
+func TestScriptErrorInModule(t *testing.T) {
+	fsys := fstest.MapFS{"lib.js": {Data: []byte(`exports.run = function () { null.x; };`)}}
+	_, err := runScript(t, "module", `require("./lib").run();`, nil, WithModuleFS(fsys))
+	var scriptErr *ScriptError
+	if !errors.As(err, &scriptErr) {
+		t.Fatalf("error = %#v, want *ScriptError", err)
+	}
+	// 模块的包装代码与第一行在同一行，只修正列号
+	if scriptErr.Name != "TypeError" || scriptErr.File != "lib.js" || scriptErr.Line != 1 || scriptErr.Column != 34 {
+		t.Fatalf("error = %s at %s:%d:%d, want TypeError at lib.js:1:34",
+			scriptErr.Name, scriptErr.File, scriptErr.Line, scriptErr.Column)
+	}
+	if last := scriptErr.Stack[len(scriptErr.Stack)-1]; last.File != "module.js" || last.Line != 1 {
+		t.Fatalf("caller frame = %+v, want module.js:1", last)
+	}
+}
+
+func TestScriptErrorThrownValue(t *testing.T) {
+	_, err := runScript(t, "value", `throw {code: 7};`, nil)
+	var scriptErr *ScriptError
+	if !errors.As(err, &scriptErr) {
+		t.Fatalf("error = %#v, want *ScriptError", err)
+	}
+	if scriptErr.Name != "" || !reflect.DeepEqual(scriptErr.Value, map[string]interface{}{"code": int64(7)}) {
+		t.Fatalf("error = %+v, want the thrown object", scriptErr)
+	}
+}
@@ -0,0 +1,259 @@
+package jsrun
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// ErrPromisePending 脚本返回的Promise既未完成，也没有可推进它的定时器或宿主任务
+var ErrPromisePending = errors.New("Promise 未完成且没有待执行的任务")
+
+// queueMicrotask 借助Promise任务队列实现，保证与 await 的执行顺序一致
+var queueMicrotaskProg = goja.MustCompile("queueMicrotask.js",
+	"(function(cb){ if (typeof cb !== 'function') { throw new TypeError('callback is not a function'); } Promise.resolve().then(function(){ cb(); }); })", true)
+
+type jsTimer struct {
+	timer    *time.Timer
+	fn       goja.Callable
+	args     []goja.Value
+	delay    time.Duration
+	interval bool
+}
+
+// eventLoop 运行时的事件循环，每次Run结束时关闭，归还到池中前复位
+// 所有JS回调都在执行脚本的同一个协程中运行，其他协程只能通过 push 投递任务
+type eventLoop struct {
+	vm      *goja.Runtime
+	limiter *limiter        // 本次Run的资源监控
+	ctx     context.Context // 本次Run的 context，传给异步宿主任务
+
+	mu      sync.Mutex
+	jobs    []func() error
+	wakeup  chan struct{}
+	timers  map[int64]*jsTimer
+	timerId int64
+	pending int // 尚未完成的宿主异步任务数
+	closed  bool
+	gen     int // 每次复位加1，丢弃上一次Run遗留的宿主回调
+}
+
+func newEventLoop(vm *goja.Runtime) *eventLoop {
+	return &eventLoop{
+		vm:     vm,
+		wakeup: make(chan struct{}, 1),
+		timers: make(map[int64]*jsTimer),
+	}
+}
+
+// 注册 setTimeout/setInterval/clearTimeout/clearInterval/queueMicrotask
+func (l *eventLoop) install() error {
+	l.vm.Set("setTimeout", func(call goja.FunctionCall) goja.Value {
+		return l.vm.ToValue(l.addTimer(call, false))
+	})
+	l.vm.Set("setInterval", func(call goja.FunctionCall) goja.Value {
+		return l.vm.ToValue(l.addTimer(call, true))
+	})
+	l.vm.Set("clearTimeout", func(id int64) {
+		l.clearTimer(id)
+	})
+	l.vm.Set("clearInterval", func(id int64) {
+		l.clearTimer(id)
+	})
+	queueMicrotask, err := l.vm.RunProgram(queueMicrotaskProg)
+	if err != nil {
+		return err
+	}
+	l.vm.Set("queueMicrotask", queueMicrotask)
+	return nil
+}
+
+func (l *eventLoop) addTimer(call goja.FunctionCall, interval bool) int64 {
+	fn, ok := goja.AssertFunction(call.Argument(0))
+	if !ok {
+		panic(l.vm.NewTypeError("callback is not a function"))
+	}
+	delay := time.Duration(call.Argument(1).ToInteger()) * time.Millisecond
+	if delay < 0 {
+		delay = 0
+	}
+	var args []goja.Value
+	if len(call.Arguments) > 2 {
+		args = append(args, call.Arguments[2:]...)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return 0
+	}
+	l.timerId++
+	id := l.timerId
+	t := &jsTimer{fn: fn, args: args, delay: delay, interval: interval}
+	l.timers[id] = t
+	l.armTimer(id, t)
+	return id
+}
+
+// 到期后把回调投递到事件循环，调用方需持有 l.mu
+func (l *eventLoop) armTimer(id int64, t *jsTimer) {
+	t.timer = time.AfterFunc(t.delay, func() {
+		l.push(func() error {
+			l.mu.Lock()
+			current, ok := l.timers[id]
+			if ok && !t.interval {
+				delete(l.timers, id)
+			}
+			l.mu.Unlock()
+			if !ok || current != t {
+				// 已被清除
+				return nil
+			}
+			_, err := t.fn(goja.Undefined(), t.args...)
+			if err == nil && t.interval {
+				l.mu.Lock()
+				if l.timers[id] == t && !l.closed {
+					l.armTimer(id, t)
+				}
+				l.mu.Unlock()
+			}
+			return err
+		})
+	})
+}
+
+func (l *eventLoop) clearTimer(id int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if t, ok := l.timers[id]; ok {
+		t.timer.Stop()
+		delete(l.timers, id)
+	}
+}
+
+// 投递任务，可在任意协程调用
+func (l *eventLoop) push(job func() error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return
+	}
+	l.jobs = append(l.jobs, job)
+	l.mu.Unlock()
+	select {
+	case l.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// hold 登记一个宿主异步任务，返回的函数在任务完成时把回调投递回事件循环
+// 登记期间事件循环会一直等待，直到Promise完成或超时
+func (l *eventLoop) hold() func(job func() error) {
+	l.mu.Lock()
+	l.pending++
+	gen := l.gen
+	l.mu.Unlock()
+	var once sync.Once
+	return func(job func() error) {
+		once.Do(func() {
+			l.mu.Lock()
+			if l.gen != gen {
+				l.mu.Unlock()
+				return
+			}
+			l.pending--
+			l.mu.Unlock()
+			l.push(job)
+		})
+	}
+}
+
+// newPromise 创建一个由宿主完成的Promise，返回的 resolve/reject 可在任意协程调用
+// 必须在事件循环协程中调用
+func (l *eventLoop) newPromise() (*goja.Promise, func(result interface{}), func(reason interface{})) {
+	promise, resolve, reject := l.vm.NewPromise()
+	done := l.hold()
+	return promise,
+		func(result interface{}) {
+			done(func() error {
+				resolve(result)
+				return nil
+			})
+		},
+		func(reason interface{}) {
+			done(func() error {
+				if err, ok := reason.(error); ok {
+					reason = l.vm.NewGoError(err)
+				}
+				reject(reason)
+				return nil
+			})
+		}
+}
+
+// wait 运行事件循环直到 promise 完成、ctx 结束或没有可推进的任务
+func (l *eventLoop) wait(ctx context.Context, promise *goja.Promise) error {
+	for promise.State() == goja.PromiseStatePending {
+		l.mu.Lock()
+		jobs := l.jobs
+		l.jobs = nil
+		idle := len(jobs) == 0 && len(l.timers) == 0 && l.pending == 0
+		l.mu.Unlock()
+
+		if idle {
+			return ErrPromisePending
+		}
+		if len(jobs) == 0 {
+			select {
+			case <-l.wakeup:
+			case <-ctx.Done():
+				return context.Cause(ctx)
+			case <-l.limiter.failCh():
+				return l.limiter.err()
+			}
+			continue
+		}
+		for _, job := range jobs {
+			l.limiter.enter()
+			err := job()
+			l.limiter.leave()
+			if err != nil {
+				return err
+			}
+			if promise.State() != goja.PromiseStatePending {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// reset 运行时归还到池中前调用，使事件循环可再次使用
+func (l *eventLoop) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = false
+	l.ctx = nil
+	l.jobs = nil
+	l.pending = 0
+	l.gen++
+	select {
+	case <-l.wakeup:
+	default:
+	}
+}
+
+// close 停止所有定时器，之后投递的任务将被丢弃
+func (l *eventLoop) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	for id, t := range l.timers {
+		t.timer.Stop()
+		delete(l.timers, id)
+	}
+	l.jobs = nil
+}
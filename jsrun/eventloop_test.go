@@ -0,0 +1,88 @@
+package jsrun
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEventLoop(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want interface{}
+	}{
+		{"clear interval", `var n = 0;
+			await new Promise(function (r) {
+				var id = setInterval(function () {
+					n++;
+					if (n === 3) { clearInterval(id); setTimeout(r, 30); }
+				}, 1);
+			});
+			return n;`, int64(3)},
+		{"clear timeout", `var fired = false;
+			clearTimeout(setTimeout(function () { fired = true; }, 1));
+			await new Promise(function (r) { setTimeout(r, 20); });
+			return fired;`, false},
+		{"timer arguments", `return await new Promise(function (r) { setTimeout(r, 0, "arg"); });`, "arg"},
+		{"microtask order", `var log = [];
+			setTimeout(function () { log.push("timeout"); }, 0);
+			queueMicrotask(function () { log.push("micro"); });
+			Promise.resolve().then(function () { log.push("then"); });
+			log.push("sync");
+			await new Promise(function (r) { setTimeout(r, 10); });
+			return log.join(",");`, "sync,micro,then,timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := runScript(t, tt.name, tt.code, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result != tt.want {
+				t.Fatalf("result = %#v, want %#v", result, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromisePending(t *testing.T) {
+	_, err := runScript(t, "pending", `await new Promise(function () {}); return 1;`, nil)
+	if !errors.Is(err, ErrPromisePending) {
+		t.Fatalf("error = %v, want ErrPromisePending", err)
+	}
+}
+
+// 出错或超时后事件循环关闭，未触发的定时器不再执行
+func TestEventLoopClosed(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		timeout int
+	}{
+		{"error", `setTimeout(function () { $d.fired = true; }, 50); throw new Error("failed");`, 5},
+		{"timeout", `setTimeout(function () { $d.fired = true; }, 1200);
+			await new Promise(function () { setTimeout(function () {}, 5000); });`, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prog, err := JSRun.Compile(tt.name, tt.code, testTool)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			d := map[string]interface{}{"fired": false}
+			timeout := tt.timeout
+			if _, err = JSRun.Run(&ctx, prog, testTool, map[string]interface{}{"d": d},
+				map[string]*sync.RWMutex{}, false, 1, &timeout); err == nil {
+				t.Fatal("Run returned no error")
+			}
+			time.Sleep(400 * time.Millisecond)
+			if d["fired"] != false {
+				t.Fatal("timer fired after Run returned")
+			}
+		})
+	}
+}
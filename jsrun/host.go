@@ -0,0 +1,41 @@
+package jsrun
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dop251/goja"
+	"github.com/skyfox2000/nect-utils/ants"
+	"github.com/skyfox2000/nect-utils/jsmodule"
+)
+
+// 异步宿主任务的实现：work 提交到工具的异步协程池，完成后通过事件循环 resolve/reject Promise
+// 脚本执行结束后才完成的任务，其结果会被丢弃
+func (p *jsrunStruct) asyncHost(entry *runtimeEntry) func(work jsmodule.AsyncWork) goja.Value {
+	return func(work jsmodule.AsyncWork) goja.Value {
+		loop := entry.loop
+		ctx := loop.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		promise, resolve, reject := loop.newPromise()
+
+		err := ants.Ants.Submit(entry.tool.Name+".async", func() {
+			defer func() {
+				if r := recover(); r != nil {
+					reject(fmt.Errorf("async host function panic: %v", r))
+				}
+			}()
+			result, err := work(ctx)
+			if err != nil {
+				reject(err)
+				return
+			}
+			resolve(result)
+		}, p.AsyncPoolSize)
+		if err != nil {
+			reject(err)
+		}
+		return entry.vm.ToValue(promise)
+	}
+}
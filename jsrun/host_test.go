@@ -0,0 +1,101 @@
+package jsrun
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dop251/goja"
+	utils "github.com/skyfox2000/nect-utils"
+	"github.com/skyfox2000/nect-utils/jsmodule"
+)
+
+// 注册测试用的异步宿主模块，stopped 在 block 的 ctx 结束时关闭
+func registerAsyncModule(t *testing.T) chan struct{} {
+	stopped := make(chan struct{})
+	jsmodule.DefaultRegistry.Register("asyncTest", func(mc *jsmodule.ModuleContext) interface{} {
+		return map[string]interface{}{
+			"value": func(v interface{}) goja.Value {
+				return mc.Promise(func(ctx context.Context) (interface{}, error) {
+					time.Sleep(5 * time.Millisecond)
+					return v, nil
+				})
+			},
+			"fail": func(msg string) goja.Value {
+				return mc.Promise(func(ctx context.Context) (interface{}, error) {
+					return nil, errors.New(msg)
+				})
+			},
+			"panic": func() goja.Value {
+				return mc.Promise(func(ctx context.Context) (interface{}, error) {
+					panic("broken")
+				})
+			},
+			"block": func() goja.Value {
+				return mc.Promise(func(ctx context.Context) (interface{}, error) {
+					<-ctx.Done()
+					close(stopped)
+					return nil, ctx.Err()
+				})
+			},
+		}
+	})
+	t.Cleanup(func() { jsmodule.DefaultRegistry.Unregister("asyncTest") })
+	return stopped
+}
+
+func TestAsyncHostPromise(t *testing.T) {
+	registerAsyncModule(t)
+	tests := []struct {
+		name string
+		code string
+		want interface{}
+	}{
+		{"resolve", `return await require("asyncTest").value(5);`, int64(5)},
+		{"all", `var m = require("asyncTest");
+			var r = await Promise.all([m.value("a"), m.value("b")]);
+			return r.join(",");`, "a,b"},
+		{"reject", `try { await require("asyncTest").fail("bad input"); } catch (e) { return String(e.message); }`, "bad input"},
+		{"panic", `try { await require("asyncTest").panic(); } catch (e) { return String(e.message).indexOf("broken") >= 0; }`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := runScript(t, tt.name, tt.code, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result != tt.want {
+				t.Fatalf("result = %#v, want %#v", result, tt.want)
+			}
+		})
+	}
+
+	// 未捕获的 reject 作为脚本异常返回
+	_, err := runScript(t, "uncaught", `await require("asyncTest").fail("bad input");`, nil)
+	if !errors.Is(err, utils.NewError(ErrnoScript, "")) {
+		t.Fatalf("error = %v, want errno %d", err, ErrnoScript)
+	}
+}
+
+// 等待宿主任务时取消执行，任务的 ctx 随之结束
+func TestAsyncHostCancel(t *testing.T) {
+	stopped := registerAsyncModule(t)
+	prog, err := JSRun.Compile("cancel", `await require("asyncTest").block(); return 1;`, testTool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	timeout := 5
+	_, err = JSRun.Run(&ctx, prog, testTool, nil, map[string]*sync.RWMutex{}, false, 1, &timeout)
+	if !errors.Is(err, utils.NewError(ErrnoCanceled, "")) {
+		t.Fatalf("error = %v, want errno %d", err, ErrnoCanceled)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("host task context not canceled")
+	}
+}
@@ -0,0 +1,120 @@
+package jsrun
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/skyfox2000/nect-utils/json"
+)
+
+// DataMode $ 注入数据的隔离方式
+// 数据中的 json.Number 与 uint64 按 json.JSON.SafeNumber 转换：安全范围内的整数与不超过15位有效数字的小数转换为数字，
+// 其他（如64位ID、高精度金额）转换为字符串。运行时不支持 BigInt，脚本中得到的是字符串
+type DataMode int
+
+const (
+	DataShared DataMode = iota // 直接注入调用方的数据，脚本的修改会写回调用方（默认，兼容原有行为）；含 json.Number 的 map 与数组注入转换后的副本，这部分修改不写回
+	DataFrozen                 // 深度冻结的副本，脚本修改时抛出 TypeError
+	DataCopy                   // 深拷贝的副本，脚本可以修改，修改只在本次执行内可见
+)
+
+// 按隔离方式注入 $ 变量，writable 中的变量始终直接注入，用于脚本回写结果
+// 直接注入的数据不修改调用方的数字，需要转换时只复制含 json.Number 或 uint64 的 map 与数组
+func (p *jsrunStruct) injectData(vm *goja.Runtime, data map[string]interface{}, mode DataMode, writable []string) error {
+	var freeze goja.Callable
+	if mode == DataFrozen {
+		freeze, _ = goja.AssertFunction(vm.Get("Object").ToObject(vm).Get("freeze"))
+	}
+	for k, v := range data {
+		if mode == DataShared || containsKey(writable, k) {
+			safe, _ := json.JSON.SafeNumbers(v, false)
+			if err := vm.Set("$"+k, safe); err != nil {
+				return err
+			}
+			continue
+		}
+		value, err := isolatedValue(vm, v, freeze)
+		if err != nil {
+			return err
+		}
+		if err = vm.Set("$"+k, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 将 Go 数据复制为 JS 原生对象与数组，freeze 不为空时逐层冻结
+// 复制后的对象与调用方数据无关，导出时得到的是新的 map/slice
+func isolatedValue(vm *goja.Runtime, v interface{}, freeze goja.Callable) (goja.Value, error) {
+	var obj *goja.Object
+	switch t := v.(type) {
+	case stdjson.Number, uint64:
+		return vm.ToValue(json.JSON.SafeNumber(t)), nil
+	case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32,
+		float32, float64, time.Time, []byte, goja.Value:
+		return vm.ToValue(v), nil
+	case map[string]interface{}:
+		obj = vm.NewObject()
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child, err := isolatedValue(vm, t[k], freeze)
+			if err != nil {
+				return nil, err
+			}
+			if err = obj.Set(k, child); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		items := make([]interface{}, len(t))
+		for i, item := range t {
+			child, err := isolatedValue(vm, item, freeze)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = child
+		}
+		obj = vm.NewArray(items...)
+	default:
+		// 其他 map、slice、结构体等转换为通用的 JSON 结构后再复制
+		switch reflect.ValueOf(v).Kind() {
+		case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct, reflect.Ptr:
+			data, err := stdjson.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			var generic interface{}
+			decoder := stdjson.NewDecoder(bytes.NewReader(data))
+			decoder.UseNumber()
+			if err = decoder.Decode(&generic); err != nil {
+				return nil, err
+			}
+			return isolatedValue(vm, generic, freeze)
+		}
+		return vm.ToValue(v), nil
+	}
+	if freeze != nil {
+		if _, err := freeze(goja.Undefined(), obj); err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
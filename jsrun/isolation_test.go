@@ -0,0 +1,89 @@
+package jsrun
+
+import (
+	stdjson "encoding/json"
+	"testing"
+)
+
+func TestSharedDataWithNumbers(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []RunOption
+	}{
+		{"shared", nil},
+		{"writable", []RunOption{WithDataMode(DataCopy), WithWritable("d", "plain")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := map[string]interface{}{"id": stdjson.Number("9007199254740993"), "n": stdjson.Number("41")}
+			plain := map[string]interface{}{"v": 0}
+			result, err := runScript(t, "numbers", `$plain.v = $d.n + 1; return typeof $d.id;`,
+				map[string]interface{}{"d": d, "plain": plain}, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result != "string" {
+				t.Fatalf("typeof $d.id = %v, want string", result)
+			}
+			if d["id"] != stdjson.Number("9007199254740993") || d["n"] != stdjson.Number("41") {
+				t.Fatalf("caller's numbers were modified: %#v", d)
+			}
+			if plain["v"] != int64(42) {
+				t.Fatalf("script write not visible to caller: %#v", plain)
+			}
+		})
+	}
+}
+
+func TestJSONModuleParseUseNumber(t *testing.T) {
+	result, err := runScript(t, "parse", `var JSON2 = require("JSON");
+		var v = JSON2.parse('{"id":9007199254740993,"n":41,"f":0.5}', {useNumber: true});
+		var plain = JSON2.parse('{"n":41}');
+		return [typeof v.id, v.id, v.n + 1, v.f, plain.n + 1];`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{"string", "9007199254740993", int64(42), 0.5, int64(42)}
+	got := result.([]interface{})
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("result = %#v, want %#v", got, want)
+		}
+	}
+}
+
+func TestDataModes(t *testing.T) {
+	type point struct{ X int }
+	tests := []struct {
+		name     string
+		opts     []RunOption
+		want     interface{}
+		modified bool
+	}{
+		{"shared", nil, true, true},
+		{"frozen", []RunOption{WithDataMode(DataFrozen)}, false, false},
+		{"copy", []RunOption{WithDataMode(DataCopy)}, true, false},
+		{"copy writable", []RunOption{WithDataMode(DataCopy), WithWritable("d")}, true, true},
+		{"frozen writable", []RunOption{WithDataMode(DataFrozen), WithWritable("d")}, true, true},
+	}
+	code := `try { $d.list[0] = 9; $d.obj.v = 9; return $d.list[0] === 9 && $d.obj.v === 9 && $p.X === 1; }
+		catch (e) { return !(e instanceof TypeError); }`
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := []interface{}{1}
+			obj := map[string]interface{}{"v": 1}
+			d := map[string]interface{}{"list": list, "obj": obj}
+			result, err := runScript(t, "modes", code, map[string]interface{}{"d": d, "p": point{X: 1}}, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result != tt.want {
+				t.Fatalf("result = %#v, want %#v", result, tt.want)
+			}
+			modified := list[0] != 1 || obj["v"] != 1
+			if modified != tt.modified {
+				t.Fatalf("caller data modified = %v, want %v: %#v", modified, tt.modified, d)
+			}
+		})
+	}
+}
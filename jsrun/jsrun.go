@@ -2,32 +2,65 @@ package jsrun
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/dop251/goja"
 	utils "github.com/skyfox2000/nect-utils"
-	"github.com/skyfox2000/nect-utils/ants"
 	"github.com/skyfox2000/nect-utils/async"
-	"github.com/skyfox2000/nect-utils/jsmodule"
 	"github.com/skyfox2000/nect-utils/json"
 	"github.com/skyfox2000/nect-utils/underscore"
 )
 
 // JSRun 对应的结构体
 var JSRun = &jsrunStruct{}
-var vm *goja.Runtime
+
+// 脚本执行错误码
+const (
+	ErrnoScript   = 3001 // 脚本执行异常
+	ErrnoTimeout  = 3002 // 脚本执行超时
+	ErrnoCanceled = 3003 // 调用方取消执行
+	ErrnoLimit    = 3004 // 超出资源限制
+)
+
+// ErrTimeout 脚本执行超时，可用 errors.Is(err, jsrun.ErrTimeout) 判断
+var ErrTimeout = utils.NewError(ErrnoTimeout, "脚本执行超时")
+
+// ErrCanceled 调用方取消执行，可用 errors.Is(err, jsrun.ErrCanceled) 判断
+var ErrCanceled = utils.NewError(ErrnoCanceled, "脚本执行已取消")
+
+// ErrLimit 超出资源限制，Data 为 *LimitError
+var ErrLimit = utils.NewError(ErrnoLimit, "超出资源限制")
 
 type jsrunStruct struct {
+	RuntimePoolSize  int       // 空闲运行时池大小，默认20
+	MaxRuntimeUses   int       // 单个运行时最多复用次数，默认1000
+	ProgramCacheSize int       // 编译缓存数量，默认1000
+	Limits           RunLimits // 默认资源限制，为 0 的字段使用默认值，可通过 WithLimits 单独设置
+	ModuleFS         fs.FS     // 用户模块的来源，可用 os.DirFS 指定目录，可通过 WithModuleFS 单独设置
+	DataMode         DataMode  // $ 注入数据的隔离方式，默认 DataShared，可通过 WithDataMode 单独设置
+	AsyncPoolSize    int       // 异步宿主任务的协程池大小，每个工具单独一个池，默认30
+
+	poolOnce       sync.Once
+	runtimes       chan *runtimeEntry
+	runtimeCreated int64
+	runtimeReused  int64
+
+	programMutex  sync.RWMutex
+	programs      map[string]*goja.Program
+	sources       map[string]*scriptSource
+	programHits   int64
+	programMisses int64
 }
 
 func (p *jsrunStruct) Compile(jsName, jscodeStr string, utilsTool utils.UtilsTool) (*goja.Program, error) {
 	jsCode := jscodeStr
-	if vm == nil {
-		vm = goja.New()
-	}
 
 	prepareCode := fmt.Sprintf("\"use strict\";\n(async function(){\n%s\n})();", jsCode)
 
@@ -35,6 +68,15 @@ func (p *jsrunStruct) Compile(jsName, jscodeStr string, utilsTool utils.UtilsToo
 		utilsTool.Logger.Debug("["+utilsTool.Name+"] ", jsName, ", jsCode: \n", prepareCode)
 	}
 
+	// 保存源码，运行出错时用于修正行号与输出代码片段
+	p.storeSource(jsName+".js", &scriptSource{code: jsCode, lineOffset: wrapperLines})
+
+	// 代码未变化时直接使用缓存的编译结果
+	key := programKey(jsName, jsCode)
+	if prog, ok := p.loadProgram(key); ok {
+		return prog, nil
+	}
+
 	prog, err := goja.Compile(jsName+".js", prepareCode, false)
 	if err != nil {
 		lines := strings.Split(prepareCode, "\n")
@@ -44,6 +86,7 @@ func (p *jsrunStruct) Compile(jsName, jscodeStr string, utilsTool utils.UtilsToo
 		utilsTool.Logger.Error("["+utilsTool.Name+"] error compiling script: \n", strings.Join(lines, "\n"))
 		return nil, err
 	}
+	p.storeProgram(key, prog)
 
 	return prog, nil
 }
@@ -56,109 +99,200 @@ func (p *jsrunStruct) Run(
 	keyMutexes map[string]*sync.RWMutex,
 	cacheFlag bool,
 	concurrent int,
-	timeout *int) (interface{}, error) {
+	timeout *int,
+	opts ...RunOption) (interface{}, error) {
 
-	newVm := goja.New()
-	newVm.Set("require", func(call goja.FunctionCall) goja.Value {
-		return p.require(call, utilsTool)
-	})
+	cfg := p.newRunConfig(opts)
+	if cfg.report == nil {
+		return p.execute(ctx, prog, utilsTool, data, keyMutexes, concurrent, timeout, cfg)
+	}
 
-	for k, v := range data {
-		newVm.Set("$"+k, v)
-	}
-
-	// 注册 console 对象
-	console := map[string]func(args ...interface{}){
-		"log": func(args ...interface{}) {
-			p.consoleLog("log", utilsTool, args...)
-		},
-		"info": func(args ...interface{}) {
-			p.consoleLog("info", utilsTool, args...)
-		},
-		"warn": func(args ...interface{}) {
-			p.consoleLog("warn", utilsTool, args...)
-		},
-		"debug": func(args ...interface{}) {
-			p.consoleLog("debug", utilsTool, args...)
-		},
-		"error": func(args ...interface{}) {
-			p.consoleLog("error", utilsTool, args...)
-		},
-	}
-	newVm.Set("console", console)
+	// 收集执行报告
+	cfg.console = &consoleCapture{}
+	result, err := p.execute(ctx, prog, utilsTool, data, keyMutexes, concurrent, timeout, cfg)
+	cfg.report.Result = result
+	cfg.report.Error = err
+	cfg.report.Console, cfg.report.Truncated = cfg.console.close()
+	return result, err
+}
+
+func (p *jsrunStruct) execute(
+	ctx *context.Context,
+	prog *goja.Program,
+	utilsTool utils.UtilsTool,
+	data map[string]interface{},
+	keyMutexes map[string]*sync.RWMutex,
+	concurrent int,
+	timeout *int,
+	cfg *runConfig) (interface{}, error) {
+
+	entry := p.getRuntime(utilsTool)
+	newVm := entry.vm
+	loop := entry.loop
+
+	// 资源限制
+	lim := newLimiter(newVm, cfg.limits)
+	entry.limiter = lim
+	loop.limiter = lim
+	entry.loader = newModuleLoader(cfg.moduleFS)
+	entry.console = cfg.console
+
+	// 按隔离方式注入数据，避免脚本修改调用方的数据
+	if err := p.injectData(newVm, data, cfg.dataMode, cfg.writable); err != nil {
+		lim.stop()
+		loop.close()
+		p.putRuntime(entry)
+		return nil, err
+	}
 
 	for _, mutex := range keyMutexes {
 		mutex.Lock()
 	}
 
-	result, ex := async.Async.AsyncRun(func() (interface{}, error) {
+	runCtx := context.Background()
+	if ctx != nil && *ctx != nil {
+		runCtx = *ctx
+	}
+
+	// 超时或取消时中断脚本，避免死循环脚本一直占用协程池
+	// state：0 未开始，1 运行中，2 已放弃（超时时仍在排队，之后不再运行）；运行中的脚本退出后关闭 finished
+	submitted := time.Now()
+	var started int64
+	var state int32
+	finished := make(chan struct{})
+	result, ex := async.Async.AsyncRunContext(runCtx, func(execCtx context.Context) (interface{}, error) {
+		if !atomic.CompareAndSwapInt32(&state, 0, 1) {
+			return nil, execCtx.Err()
+		}
+		defer close(finished)
+		atomic.StoreInt64(&started, time.Now().UnixNano())
+		loop.ctx = execCtx
+		lim.enter()
 		r, e := newVm.RunProgram(prog)
-		return r, e
+		lim.leave()
+		if e != nil {
+			return nil, e
+		}
+		// 如果是 Promise，运行事件循环直到其完成
+		if promise, ok := r.Export().(*goja.Promise); ok {
+			if e = loop.wait(execCtx, promise); e != nil {
+				return nil, e
+			}
+		}
+		return r, nil
+	}, func(err error) {
+		newVm.Interrupt(err)
 	}, utilsTool.Name, concurrent, timeout)
 
+	// 超时或取消时脚本收到中断后才退出，等待其返回后再释放锁、停止限制并关闭事件循环
+	if !atomic.CompareAndSwapInt32(&state, 0, 2) {
+		<-finished
+	}
+
 	for _, mutex := range keyMutexes {
 		mutex.Unlock()
 	}
 
-	// 等待结果或错误
-	finalResult := result.(goja.Value)
-	finalErr := ex
-
-	for k := range data {
-		newVm.GlobalObject().Delete("$" + k)
+	if cfg.report != nil {
+		if start := atomic.LoadInt64(&started); start > 0 {
+			cfg.report.PoolWait = time.Unix(0, start).Sub(submitted)
+			cfg.report.Duration = time.Since(time.Unix(0, start))
+		} else {
+			cfg.report.PoolWait = time.Since(submitted)
+		}
 	}
 
-	if finalErr != nil {
-		return nil, finalErr
+	// 等待结果或错误
+	var interrupted *goja.InterruptedError
+	var stackOverflow *goja.StackOverflowError
+	// 超时或取消时脚本可能仍在退出中，被中断、栈溢出或 panic 后运行时内部状态不完整，都不再归还到池中
+	reusable := !errors.Is(ex, async.ErrTimeout) && !errors.Is(ex, async.ErrCanceled) &&
+		!errors.Is(ex, async.ErrPanic) && !errors.As(ex, &interrupted) && !errors.As(ex, &stackOverflow)
+	if limitErr := lim.err(); limitErr != nil {
+		ex = limitErr
+		reusable = false
+	} else if stackOverflow != nil {
+		ex = &LimitError{Kind: LimitCallStack, Limit: cfg.limits.MaxCallStackSize}
+	}
+	lim.stop()
+	// 无论运行时是否复用都关闭事件循环，停止未触发的定时器
+	loop.close()
+	if ex != nil {
+		err := p.runError(ex, utilsTool)
+		if reusable {
+			p.putRuntime(entry)
+		}
+		return nil, err
 	}
+	defer p.putRuntime(entry)
+	finalResult := result.(goja.Value)
 
 	// 判断是否是 Promise
 	if promise, ok := finalResult.Export().(*goja.Promise); ok {
-		next := make(chan bool)
-		var promiseResult interface{}
-		chanResult := make(chan interface{}, 1)
-		ants.Ants.Submit("JSRun.Promise", func() {
-			defer close(next)
-			// 如果是 Promise，等待异步操作完成，并获取结果
-			var pr interface{}
-			pr = promise.Result()
-
-			if ok := promise.Result() == nil; ok {
-				pr = nil
-			} else if errStr, ok := p.isError(promise.Result()); ok {
-				pr = utils.NewError(3001, "["+utilsTool.Name+"] "+errStr)
-			} else {
-				pr = promise.Result().Export()
-			}
-			chanResult <- pr
-		}, concurrent)
-		<-next
+		return p.promiseResult(promise, utilsTool)
+	}
 
-		promiseResult = <-chanResult
-		if errResult, ok := promiseResult.(*utils.CustomError); ok {
-			return nil, errResult
-		}
+	return nil, nil
+}
 
-		if errResult, ok := promiseResult.(map[string]interface{}); ok {
-			errno, ok1 := errResult["errno"].(int64)
-			msg, ok2 := errResult["msg"].(string)
-			detail := errResult["detail"]
-			if ok1 && ok2 {
-				err := &utils.CustomError{
-					Errno: int(errno), 
-					Msg: "["+utilsTool.Name+"] "+msg, 
-					Data: detail,
-				}
-				return nil, err
+// 获取已完成Promise的结果，reject 或返回 {errno, msg} 结构时转换为错误
+func (p *jsrunStruct) promiseResult(promise *goja.Promise, utilsTool utils.UtilsTool) (interface{}, error) {
+	result := promise.Result()
+	rejected := promise.State() == goja.PromiseStateRejected
+	var promiseResult interface{}
+	if result == nil || goja.IsUndefined(result) || goja.IsNull(result) {
+		promiseResult = nil
+	} else if _, ok := p.isError(result); ok {
+		return nil, p.scriptFailure(p.newScriptError(result, ""), utilsTool)
+	} else {
+		promiseResult = result.Export()
+	}
+
+	if errResult, ok := promiseResult.(map[string]interface{}); ok {
+		errno, ok1 := errResult["errno"].(int64)
+		msg, ok2 := errResult["msg"].(string)
+		detail := errResult["detail"]
+		if ok1 && ok2 {
+			err := &utils.CustomError{
+				Errno: int(errno),
+				Msg:   "[" + utilsTool.Name + "] " + msg,
+				Data:  detail,
 			}
+			return nil, err
 		}
+	}
 
-		// JS不能修改系统数据
-
-		return promiseResult, nil
+	if rejected {
+		return nil, p.scriptFailure(p.newScriptError(result, ""), utilsTool)
 	}
 
-	return nil, nil
+	return promiseResult, nil
+}
+
+// 将执行错误转换为对应错误码的 CustomError
+func (p *jsrunStruct) runError(err error, utilsTool utils.UtilsTool) error {
+	var interrupted *goja.InterruptedError
+	if errors.As(err, &interrupted) {
+		if e, ok := interrupted.Value().(error); ok {
+			err = e
+		}
+	}
+	err = p.exceptionError(err)
+	var limitErr *LimitError
+	var scriptErr *ScriptError
+	switch {
+	case errors.As(err, &scriptErr):
+		return p.scriptFailure(scriptErr, utilsTool)
+	case errors.As(err, &limitErr):
+		return &utils.CustomError{Errno: ErrnoLimit, Msg: "[" + utilsTool.Name + "] " + limitErr.Error(), Data: limitErr}
+	case errors.Is(err, async.ErrTimeout):
+		return &utils.CustomError{Errno: ErrnoTimeout, Msg: "[" + utilsTool.Name + "] " + err.Error(), Data: err}
+	case errors.Is(err, async.ErrCanceled):
+		return &utils.CustomError{Errno: ErrnoCanceled, Msg: "[" + utilsTool.Name + "] " + err.Error(), Data: err}
+	case errors.Is(err, ErrPromisePending):
+		return &utils.CustomError{Errno: ErrnoScript, Msg: "[" + utilsTool.Name + "] " + err.Error(), Data: err}
+	}
+	return err
 }
 
 func (p *jsrunStruct) isError(result goja.Value) (string, bool) {
@@ -169,22 +303,28 @@ func (p *jsrunStruct) isError(result goja.Value) (string, bool) {
 	return "", false
 }
 
-func (p *jsrunStruct) consoleLog(logLevel string, utilsTool utils.UtilsTool, args ...interface{}) {
-	var message string
-	message += "[" + utilsTool.Name + "] "
+func (p *jsrunStruct) consoleLog(logLevel string, entry *runtimeEntry, args ...interface{}) {
+	utilsTool := entry.tool
+	formatted := make([]string, 0, len(args))
 
 	for _, arg := range args {
 		switch t := arg.(type) {
 		case string:
-			message += t
+			formatted = append(formatted, t)
 		default:
-			jsonStr := json.JSON.Log(t, 3, 5)
-			if message != "" {
-				message += " "
-			}
-			message += jsonStr.(string)
+			formatted = append(formatted, json.JSON.Log(t, 3, 5).(string))
 		}
 	}
+	// 参数之间以一个空格分隔
+	message := strings.Join(formatted, " ")
+	entry.console.add(ConsoleLine{
+		Level:   logLevel,
+		Time:    time.Now(),
+		Args:    formatted,
+		Message: message,
+	})
+
+	message = "[" + utilsTool.Name + "] " + message
 	switch logLevel {
 	case "log":
 		utilsTool.Logger.Info(message)
@@ -198,18 +338,3 @@ func (p *jsrunStruct) consoleLog(logLevel string, utilsTool utils.UtilsTool, arg
 		utilsTool.Logger.Error(message)
 	}
 }
-
-// 模块加载
-func (p *jsrunStruct) require(call goja.FunctionCall, utilsTool utils.UtilsTool) goja.Value {
-	moduleName := call.Argument(0).String()
-
-	modules := jsmodule.JSModules
-	module, ok := modules[moduleName]
-	if !ok {
-		err := utils.NewError(3001, "require module not found: "+moduleName)
-		utilsTool.Logger.Error(err.Error())
-		return vm.ToValue(err)
-	}
-
-	return vm.ToValue(module)
-}
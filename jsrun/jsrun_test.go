@@ -0,0 +1,81 @@
+package jsrun
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	utils "github.com/skyfox2000/nect-utils"
+)
+
+// 不主动让出的死循环在超时与取消时都被中断，Run 返回时脚本已经退出，锁已释放
+func TestInterruptBusyLoop(t *testing.T) {
+	tests := []struct {
+		name      string
+		timeout   int
+		cancel    time.Duration
+		wantErrno int
+	}{
+		{"timeout", 1, 0, ErrnoTimeout},
+		{"cancel", 5, 50 * time.Millisecond, ErrnoCanceled},
+	}
+	prog, err := JSRun.Compile("busy", `while (true) { $d.n = $d.n + 1; }`, testTool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel > 0 {
+				time.AfterFunc(tt.cancel, cancel)
+			}
+			mutex := &sync.RWMutex{}
+			d := map[string]interface{}{"n": 0}
+			timeout := tt.timeout
+			start := time.Now()
+			_, err := JSRun.Run(&ctx, prog, testTool, map[string]interface{}{"d": d},
+				map[string]*sync.RWMutex{"k": mutex}, false, 1, &timeout)
+
+			var customErr *utils.CustomError
+			if !errors.As(err, &customErr) || customErr.Errno != tt.wantErrno {
+				t.Fatalf("Run error = %v, want errno %d", err, tt.wantErrno)
+			}
+			if elapsed := time.Since(start); elapsed > time.Duration(tt.timeout)*time.Second+time.Second {
+				t.Fatalf("Run returned after %v", elapsed)
+			}
+			if !mutex.TryLock() {
+				t.Fatal("key mutex still locked after Run returned")
+			}
+			mutex.Unlock()
+			// 脚本已经退出，不再修改数据
+			n := d["n"]
+			time.Sleep(50 * time.Millisecond)
+			if d["n"] != n {
+				t.Fatalf("script still running after Run returned: %v -> %v", n, d["n"])
+			}
+		})
+	}
+}
+
+func TestRequireAllowlist(t *testing.T) {
+	tool := testTool
+	tool.Modules = []string{"JSON"}
+	prog, err := JSRun.Compile("allowlist", `var json = require("JSON");
+		try { require("Cache"); } catch (e) { return [typeof json.parse, String(e.message).indexOf("available modules: JSON") >= 0]; }
+		return "required";`, tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	timeout := 5
+	result, err := JSRun.Run(&ctx, prog, tool, nil, map[string]*sync.RWMutex{}, false, 1, &timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := result.([]interface{}); !ok || got[0] != "function" || got[1] != true {
+		t.Fatalf("result = %#v, want the allowlist error listing JSON", result)
+	}
+}
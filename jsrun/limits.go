@@ -0,0 +1,290 @@
+package jsrun
+
+import (
+	"fmt"
+	"math"
+	"runtime/metrics"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// RunLimits 单次执行的资源限制
+// MaxCallStackSize、MaxArrayLength、MaxExecTime 为 0 时使用默认值，小于 0 时不限制
+type RunLimits struct {
+	MaxCallStackSize int // 最大函数调用深度，默认 10000
+	// fill/join/repeat/padStart/padEnd/Array.from 可生成的最大长度，默认 10000000
+	// 这是对单次调用的检查，不是内存上限：new Array(n) 与 arr.length = n 在 goja 中是稀疏数组，不分配内存，不做限制；
+	// push 循环、展开运算符、逐个下标赋值等逐步增长的分配无法拦截，只能由 MaxExecTime 与 MaxProcessHeapGrowth 间接限制
+	MaxArrayLength int64
+	// 执行期间整个进程堆内存的最大增长量（字节），定时采样检测，0 表示不限制
+	// goja 无法统计单次执行的内存分配，并发执行时其他协程的分配也会计入，
+	// 只能作为粗略的进程级保护，需要留出足够余量，避免误判
+	MaxProcessHeapGrowth uint64
+	// 操作预算：脚本实际执行JS的累计时长，不含等待定时器与宿主异步任务的时间，默认 10 秒
+	// goja 不提供指令计数，以执行时长近似
+	MaxExecTime time.Duration
+}
+
+// 资源限制的默认值
+const (
+	defaultMaxCallStackSize = 10000
+	defaultMaxArrayLength   = 10000000
+	defaultMaxExecTime      = 10 * time.Second
+)
+
+// 填充默认值，小于 0 的限制转换为 0（不限制）
+func (l RunLimits) withDefaults() RunLimits {
+	switch {
+	case l.MaxCallStackSize == 0:
+		l.MaxCallStackSize = defaultMaxCallStackSize
+	case l.MaxCallStackSize < 0:
+		l.MaxCallStackSize = 0
+	}
+	switch {
+	case l.MaxArrayLength == 0:
+		l.MaxArrayLength = defaultMaxArrayLength
+	case l.MaxArrayLength < 0:
+		l.MaxArrayLength = 0
+	}
+	switch {
+	case l.MaxExecTime == 0:
+		l.MaxExecTime = defaultMaxExecTime
+	case l.MaxExecTime < 0:
+		l.MaxExecTime = 0
+	}
+	return l
+}
+
+// 资源限制类型
+const (
+	LimitCallStack   = "callStack"
+	LimitArrayLength = "arrayLength"
+	LimitProcessHeap = "processHeap"
+	LimitExecTime    = "execTime"
+)
+
+// LimitError 超出资源限制，作为 CustomError.Data 返回
+type LimitError struct {
+	Kind   string
+	Limit  interface{}
+	Actual interface{}
+}
+
+func (e *LimitError) Error() string {
+	switch e.Kind {
+	case LimitCallStack:
+		return fmt.Sprintf("超出资源限制: 调用栈深度超过 %v", e.Limit)
+	case LimitArrayLength:
+		return fmt.Sprintf("超出资源限制: 长度 %v 超过 %v", e.Actual, e.Limit)
+	case LimitProcessHeap:
+		return fmt.Sprintf("超出资源限制: 进程堆内存增长 %v 超过 %v", formatBytes(e.Actual), formatBytes(e.Limit))
+	case LimitExecTime:
+		return fmt.Sprintf("超出资源限制: 执行时长 %v 超过 %v", e.Actual, e.Limit)
+	}
+	return "超出资源限制: " + e.Kind
+}
+
+func formatBytes(v interface{}) string {
+	bytes, _ := v.(uint64)
+	return fmt.Sprintf("%.1fMB", float64(bytes)/1024/1024)
+}
+
+// 包装会一次性分配大量内存的内置方法，长度检查由 checkLength 完成
+var lengthGuardProg = goja.MustCompile("lengthGuard.js", `(function(checkLength){
+	function wrap(proto, name, length) {
+		var original = proto[name];
+		Object.defineProperty(proto, name, {
+			value: function() {
+				checkLength(length.apply(this, arguments));
+				return original.apply(this, arguments);
+			},
+			writable: true, configurable: true, enumerable: false
+		});
+	}
+	wrap(Array.prototype, 'fill', function() { return this.length; });
+	wrap(Array.prototype, 'join', function(sep) { return this.length * (sep === undefined ? 1 : String(sep).length + 1); });
+	wrap(String.prototype, 'repeat', function(n) { return String(this).length * Number(n); });
+	wrap(String.prototype, 'padStart', function(n) { return Number(n); });
+	wrap(String.prototype, 'padEnd', function(n) { return Number(n); });
+	// 稀疏数组与 {length: n} 都会按 length 生成完整的数组
+	wrap(Array, 'from', function(items) { return items == null ? 0 : Number(items.length) || 0; });
+})`, true)
+
+// 安装长度检查，超出 MaxArrayLength 时中断脚本
+func (p *jsrunStruct) installLengthGuard(entry *runtimeEntry) error {
+	guard, err := entry.vm.RunProgram(lengthGuardProg)
+	if err != nil {
+		return err
+	}
+	install, _ := goja.AssertFunction(guard)
+	_, err = install(goja.Undefined(), entry.vm.ToValue(func(length float64) {
+		lim := entry.limiter
+		if lim == nil {
+			return
+		}
+		max := lim.limits.MaxArrayLength
+		if max > 0 && length > float64(max) {
+			limitErr := &LimitError{Kind: LimitArrayLength, Limit: max, Actual: int64(length)}
+			lim.fail(limitErr)
+			panic(entry.vm.NewGoError(limitErr))
+		}
+	}))
+	return err
+}
+
+// limiter 执行期间的资源监控
+type limiter struct {
+	limits RunLimits
+	vm     *goja.Runtime
+
+	mu        sync.Mutex
+	busy      bool
+	busySince time.Time
+	busyTotal time.Duration
+	exceeded  *LimitError
+	stopped   bool
+	failed    chan struct{}
+	done      chan struct{}
+}
+
+func newLimiter(vm *goja.Runtime, limits RunLimits) *limiter {
+	callStack := limits.MaxCallStackSize
+	if callStack <= 0 {
+		callStack = math.MaxInt32
+	}
+	vm.SetMaxCallStackSize(callStack)
+
+	l := &limiter{limits: limits, vm: vm, done: make(chan struct{}), failed: make(chan struct{})}
+	if limits.MaxProcessHeapGrowth > 0 || limits.MaxExecTime > 0 {
+		go l.watch()
+	}
+	return l
+}
+
+// enter/leave 标记JS开始/结束执行，用于统计执行时长
+func (l *limiter) enter() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.busy = true
+	l.busySince = time.Now()
+	l.mu.Unlock()
+}
+
+func (l *limiter) leave() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	if l.busy {
+		l.busyTotal += time.Since(l.busySince)
+		l.busy = false
+	}
+	l.mu.Unlock()
+}
+
+func (l *limiter) execTime() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	total := l.busyTotal
+	if l.busy {
+		total += time.Since(l.busySince)
+	}
+	return total
+}
+
+// fail 记录超出的限制并中断脚本，只有第一次生效；stop 之后不再中断，避免影响归还到池中的运行时
+func (l *limiter) fail(limitErr *LimitError) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stopped || l.exceeded != nil {
+		return
+	}
+	l.exceeded = limitErr
+	l.vm.Interrupt(limitErr)
+	close(l.failed)
+}
+
+// err 返回超出的限制，未超出时返回nil
+func (l *limiter) err() *LimitError {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.exceeded
+}
+
+// failCh 超出限制时关闭，供事件循环在等待期间及时退出
+func (l *limiter) failCh() <-chan struct{} {
+	if l == nil {
+		return nil
+	}
+	return l.failed
+}
+
+func (l *limiter) stop() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.stopped {
+		l.stopped = true
+		close(l.done)
+	}
+}
+
+// 定时采样进程堆内存与执行时长，超出限制时中断脚本
+func (l *limiter) watch() {
+	interval := 10 * time.Millisecond
+	if l.limits.MaxExecTime > 0 && l.limits.MaxExecTime/10 < interval {
+		interval = l.limits.MaxExecTime / 10
+	}
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var baseHeap uint64
+	if l.limits.MaxProcessHeapGrowth > 0 {
+		baseHeap = heapBytes()
+	}
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+		}
+		if l.limits.MaxExecTime > 0 {
+			if used := l.execTime(); used > l.limits.MaxExecTime {
+				l.fail(&LimitError{Kind: LimitExecTime, Limit: l.limits.MaxExecTime, Actual: used.Round(time.Millisecond)})
+				return
+			}
+		}
+		if l.limits.MaxProcessHeapGrowth > 0 {
+			if heap := heapBytes(); heap > baseHeap && heap-baseHeap > l.limits.MaxProcessHeapGrowth {
+				l.fail(&LimitError{Kind: LimitProcessHeap, Limit: l.limits.MaxProcessHeapGrowth, Actual: heap - baseHeap})
+				return
+			}
+		}
+	}
+}
+
+var heapSample = []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+var heapMutex sync.Mutex
+
+// 进程当前堆上对象占用的字节数
+func heapBytes() uint64 {
+	heapMutex.Lock()
+	defer heapMutex.Unlock()
+	metrics.Read(heapSample)
+	if heapSample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return heapSample[0].Value.Uint64()
+}
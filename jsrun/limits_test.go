@@ -0,0 +1,52 @@
+package jsrun
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunLimitsDefaults(t *testing.T) {
+	tests := []struct {
+		name string
+		in   RunLimits
+		want RunLimits
+	}{
+		{"zero", RunLimits{}, RunLimits{MaxCallStackSize: defaultMaxCallStackSize, MaxArrayLength: defaultMaxArrayLength, MaxExecTime: defaultMaxExecTime}},
+		{"unlimited", RunLimits{MaxCallStackSize: -1, MaxArrayLength: -1, MaxExecTime: -1}, RunLimits{}},
+		{"custom", RunLimits{MaxCallStackSize: 5, MaxArrayLength: 6, MaxExecTime: time.Second, MaxProcessHeapGrowth: 7},
+			RunLimits{MaxCallStackSize: 5, MaxArrayLength: 6, MaxExecTime: time.Second, MaxProcessHeapGrowth: 7}},
+	}
+	for _, tt := range tests {
+		if got := tt.in.withDefaults(); got != tt.want {
+			t.Fatalf("%s: withDefaults() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRunLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		opts []RunOption
+		kind string
+	}{
+		{"default call stack", `function f() { return f() + 1; } return f();`, nil, LimitCallStack},
+		{"call stack", `function f(n) { return n === 0 ? 0 : f(n - 1) + 1; } return f(100);`,
+			[]RunOption{WithLimits(RunLimits{MaxCallStackSize: 50})}, LimitCallStack},
+		{"array length", `return new Array(100).fill(0).length;`, []RunOption{WithLimits(RunLimits{MaxArrayLength: 10})}, LimitArrayLength},
+		{"default array length", `return "x".repeat(1e9).length;`, nil, LimitArrayLength},
+		{"array from length", `return Array.from({length: 1e9}).length;`, nil, LimitArrayLength},
+		{"array from sparse", `return Array.from(new Array(100)).length;`, []RunOption{WithLimits(RunLimits{MaxArrayLength: 10})}, LimitArrayLength},
+		{"exec time", `while (true) {}`, []RunOption{WithLimits(RunLimits{MaxExecTime: 50 * time.Millisecond})}, LimitExecTime},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := runScript(t, "limits", tt.code, nil, tt.opts...)
+			var limitErr *LimitError
+			if !errors.Is(err, ErrLimit) || !errors.As(err, &limitErr) || limitErr.Kind != tt.kind {
+				t.Fatalf("err = %v, want %s limit", err, tt.kind)
+			}
+		})
+	}
+}
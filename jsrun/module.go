@@ -0,0 +1,167 @@
+package jsrun
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/dop251/goja"
+	utils "github.com/skyfox2000/nect-utils"
+	"github.com/skyfox2000/nect-utils/jsmodule"
+	"github.com/skyfox2000/nect-utils/json"
+)
+
+// 用户模块包装，与代码放在同一行，保证行号不偏移
+const modulePrefix = "(function(exports, require, module, __filename, __dirname){"
+const moduleSuffix = "\n})"
+
+// moduleLoader 单次执行的用户模块加载器，按 CommonJS 语义加载 ModuleFS 中的 .js/.json 文件
+// goja 不支持 ES module 语法，import/export 需改写为 require/module.exports
+type moduleLoader struct {
+	fsys    fs.FS
+	modules map[string]*goja.Object // 已加载的模块，key为模块路径
+	loading []string                // 正在加载的模块路径，用于检测循环引用
+}
+
+func newModuleLoader(fsys fs.FS) *moduleLoader {
+	return &moduleLoader{
+		fsys:    fsys,
+		modules: make(map[string]*goja.Object),
+	}
+}
+
+// 加载模块：相对路径（./ ../）相对于当前模块所在目录，/ 开头相对于模块根目录，
+// 其他名称先查找注册的 Go 模块，再查找模块根目录下的同名文件
+func (p *jsrunStruct) requireModule(entry *runtimeEntry, dir, spec string) goja.Value {
+	utilsTool := entry.tool
+	vm := entry.vm
+
+	isPath := strings.HasPrefix(spec, "./") || strings.HasPrefix(spec, "../") || strings.HasPrefix(spec, "/")
+	if !isPath {
+		key, factory, err := jsmodule.Resolve(spec, utilsTool.Modules)
+		if err == nil {
+			if module, ok := entry.modules[key]; ok {
+				return module
+			}
+			module := vm.ToValue(factory(&jsmodule.ModuleContext{
+				Runtime: vm,
+				Tool:    utilsTool,
+				Async:   p.asyncHost(entry),
+			}))
+			entry.modules[key] = module
+			return module
+		}
+		if entry.loader == nil || entry.loader.fsys == nil {
+			p.throwRequire(entry, err)
+		}
+	}
+
+	loader := entry.loader
+	if loader == nil || loader.fsys == nil {
+		p.throwRequire(entry, errors.New("require module not found: "+spec+", module source is not configured"))
+	}
+	filename, err := loader.resolve(dir, spec, isPath)
+	if err != nil {
+		p.throwRequire(entry, err)
+	}
+	if module, ok := loader.modules[filename]; ok {
+		return module.Get("exports")
+	}
+	for i, loading := range loader.loading {
+		if loading == filename {
+			chain := append(append([]string{}, loader.loading[i:]...), filename)
+			p.throwRequire(entry, errors.New("circular require: "+strings.Join(chain, " -> ")))
+		}
+	}
+
+	code, err := fs.ReadFile(loader.fsys, filename)
+	if err != nil {
+		p.throwRequire(entry, err)
+	}
+
+	module := vm.NewObject()
+	exports := vm.NewObject()
+	module.Set("exports", exports)
+	module.Set("id", filename)
+	module.Set("filename", filename)
+
+	if path.Ext(filename) == ".json" {
+		data, ok := json.JSON.Parse(code)
+		if !ok {
+			p.throwRequire(entry, errors.New("invalid json module "+filename+": "+data.(string)))
+		}
+		module.Set("exports", data)
+		loader.modules[filename] = module
+		return module.Get("exports")
+	}
+
+	prog, err := p.compileModule(filename, string(code))
+	if err != nil {
+		p.throwRequire(entry, err)
+	}
+	fn, err := vm.RunProgram(prog)
+	if err != nil {
+		panic(err)
+	}
+	call, _ := goja.AssertFunction(fn)
+
+	moduleDir := path.Dir(filename)
+	require := vm.ToValue(func(c goja.FunctionCall) goja.Value {
+		return p.requireModule(entry, moduleDir, c.Argument(0).String())
+	})
+
+	loader.loading = append(loader.loading, filename)
+	_, err = call(exports, exports, require, module, vm.ToValue(filename), vm.ToValue(moduleDir))
+	loader.loading = loader.loading[:len(loader.loading)-1]
+	if err != nil {
+		panic(err)
+	}
+
+	loader.modules[filename] = module
+	return module.Get("exports")
+}
+
+// 编译用户模块，结果与脚本共用编译缓存
+func (p *jsrunStruct) compileModule(filename, code string) (*goja.Program, error) {
+	p.storeSource(filename, &scriptSource{code: code, colOffset: len(modulePrefix)})
+	key := programKey("module:"+filename, code)
+	if prog, ok := p.loadProgram(key); ok {
+		return prog, nil
+	}
+	prog, err := goja.Compile(filename, modulePrefix+code+moduleSuffix, false)
+	if err != nil {
+		return nil, err
+	}
+	p.storeProgram(key, prog)
+	return prog, nil
+}
+
+func (p *jsrunStruct) throwRequire(entry *runtimeEntry, err error) {
+	entry.tool.Logger.Error("[" + entry.tool.Name + "] " + err.Error())
+	panic(entry.vm.NewGoError(utils.NewError(ErrnoScript, err.Error())))
+}
+
+// 解析模块路径，结果不能超出模块根目录
+func (l *moduleLoader) resolve(dir, spec string, isPath bool) (string, error) {
+	var name string
+	switch {
+	case strings.HasPrefix(spec, "/"):
+		name = path.Clean(strings.TrimLeft(spec, "/"))
+	case isPath:
+		name = path.Join(dir, spec)
+	default:
+		name = path.Clean(spec)
+	}
+	if name == ".." || strings.HasPrefix(name, "../") || !fs.ValidPath(name) {
+		return "", errors.New("require path is outside the module root: " + spec)
+	}
+
+	for _, candidate := range []string{name, name + ".js", name + ".json", path.Join(name, "index.js")} {
+		info, err := fs.Stat(l.fsys, candidate)
+		if err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", errors.New("require module not found: " + spec)
+}
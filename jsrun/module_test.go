@@ -0,0 +1,90 @@
+package jsrun
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var testModuleFS = fstest.MapFS{
+	"util.js":         {Data: []byte(`exports.double = function (v) { return v * 2; };`)},
+	"lib/math.js":     {Data: []byte(`var util = require("../util"); module.exports = { quad: function (v) { return util.double(util.double(v)); } };`)},
+	"lib/index.js":    {Data: []byte(`module.exports = { dir: __dirname, file: __filename };`)},
+	"config.json":     {Data: []byte(`{"name": "demo"}`)},
+	"counter.js":      {Data: []byte(`module.exports = { n: 0 };`)},
+	"uses_counter.js": {Data: []byte(`module.exports = require("./counter").n;`)},
+	"cycle/a.js":      {Data: []byte(`require("./b");`)},
+	"cycle/b.js":      {Data: []byte(`require("./a");`)},
+	"escape.js":       {Data: []byte(`require("../../secret");`)},
+}
+
+func TestRequireModules(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want interface{}
+	}{
+		{"relative path", `return require("./lib/math.js").quad(3);`, int64(12)},
+		{"root path", `return require("/util").double(2);`, int64(4)},
+		{"bare name", `return require("util").double(5);`, int64(10)},
+		{"directory index", `var m = require("./lib"); return m.dir + " " + m.file;`, "lib lib/index.js"},
+		{"json", `return require("./config.json").name;`, "demo"},
+		// 同一次执行中模块只执行一次，不同写法得到同一个 exports
+		{"cached", `require("./counter").n++; require("./counter.js").n++; return require("./uses_counter");`, int64(2)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := runScript(t, tt.name, tt.code, nil, WithModuleFS(testModuleFS))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result != tt.want {
+				t.Fatalf("result = %#v, want %#v", result, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireModuleErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		want string
+	}{
+		{"cycle", "./cycle/a", "circular require: cycle/a.js -> cycle/b.js -> cycle/a.js"},
+		{"parent of root", "../secret", "outside the module root"},
+		{"escape from module", "./escape", "outside the module root"},
+		{"not found", "./missing", "require module not found: ./missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := `try { require("` + tt.spec + `"); } catch (e) { return String(e.message); } return "loaded";`
+			result, err := runScript(t, tt.name, code, nil, WithModuleFS(testModuleFS))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s, _ := result.(string); !strings.Contains(s, tt.want) {
+				t.Fatalf("result = %#v, want message containing %q", result, tt.want)
+			}
+		})
+	}
+
+	// 未配置模块来源时返回错误
+	result, err := runScript(t, "no fs", `try { require("./util"); } catch (e) { return String(e.message); }`, nil)
+	if s, _ := result.(string); err != nil || !strings.Contains(s, "module source is not configured") {
+		t.Fatalf("result = %#v, %v, want module source error", result, err)
+	}
+}
+
+// 编译后的模块在多次执行间复用
+func TestModuleProgramCache(t *testing.T) {
+	first, err := JSRun.compileModule("cache.js", "module.exports = 1;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := JSRun.compileModule("cache.js", "module.exports = 1;")
+	changed, _ := JSRun.compileModule("cache.js", "module.exports = 2;")
+	if first != second || first == changed {
+		t.Fatal("compiled module cache not keyed by file name and code")
+	}
+}
@@ -0,0 +1,63 @@
+package jsrun
+
+import "io/fs"
+
+// RunOption Run 的可选参数
+type RunOption func(*runConfig)
+
+type runConfig struct {
+	limits   RunLimits
+	moduleFS fs.FS
+	report   *ExecutionReport
+	console  *consoleCapture
+	dataMode DataMode
+	writable []string
+}
+
+func (p *jsrunStruct) newRunConfig(opts []RunOption) *runConfig {
+	cfg := &runConfig{
+		limits:   p.Limits,
+		moduleFS: p.ModuleFS,
+		dataMode: p.DataMode,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	cfg.limits = cfg.limits.withDefaults()
+	return cfg
+}
+
+// WithLimits 设置本次执行的资源限制，覆盖 JSRun.Limits
+func WithLimits(limits RunLimits) RunOption {
+	return func(cfg *runConfig) {
+		cfg.limits = limits
+	}
+}
+
+// WithModuleFS 设置本次执行 require 用户模块的来源，覆盖 JSRun.ModuleFS
+func WithModuleFS(fsys fs.FS) RunOption {
+	return func(cfg *runConfig) {
+		cfg.moduleFS = fsys
+	}
+}
+
+// WithReport 执行结束后将结果、console 输出、耗时等写入 report
+func WithReport(report *ExecutionReport) RunOption {
+	return func(cfg *runConfig) {
+		cfg.report = report
+	}
+}
+
+// WithDataMode 设置本次执行 $ 注入数据的隔离方式，覆盖 JSRun.DataMode
+func WithDataMode(mode DataMode) RunOption {
+	return func(cfg *runConfig) {
+		cfg.dataMode = mode
+	}
+}
+
+// WithWritable 指定可以被脚本修改的数据，这些数据不做隔离，直接注入调用方的对象，用于脚本回写结果
+func WithWritable(keys ...string) RunOption {
+	return func(cfg *runConfig) {
+		cfg.writable = append(cfg.writable, keys...)
+	}
+}
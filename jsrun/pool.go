@@ -0,0 +1,250 @@
+package jsrun
+
+import (
+	"sync/atomic"
+
+	"github.com/dop251/goja"
+	utils "github.com/skyfox2000/nect-utils"
+	"github.com/skyfox2000/nect-utils/encrypt"
+)
+
+const (
+	defaultRuntimePoolSize  = 20
+	defaultMaxRuntimeUses   = 1000
+	defaultProgramCacheSize = 1000
+)
+
+// RunStats 运行时池与编译缓存的统计数据
+type RunStats struct {
+	ProgramHits    int64 // 编译缓存命中次数
+	ProgramMisses  int64 // 编译缓存未命中次数
+	ProgramCached  int   // 当前缓存的编译结果数
+	RuntimeCreated int64 // 新建运行时次数
+	RuntimeReused  int64 // 复用运行时次数
+	RuntimeIdle    int   // 池中空闲运行时数
+}
+
+// ProgramHitRate 编译缓存命中率
+func (s RunStats) ProgramHitRate() float64 {
+	total := s.ProgramHits + s.ProgramMisses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.ProgramHits) / float64(total)
+}
+
+// RuntimeHitRate 运行时复用率
+func (s RunStats) RuntimeHitRate() float64 {
+	total := s.RuntimeCreated + s.RuntimeReused
+	if total == 0 {
+		return 0
+	}
+	return float64(s.RuntimeReused) / float64(total)
+}
+
+var getOwnPropertyNamesProg = goja.MustCompile("getOwnPropertyNames.js", "Object.getOwnPropertyNames", true)
+
+// runtimeEntry 预初始化的运行时
+// require、console、定时器等全局函数只注册一次，通过 tool 和 loop 绑定到当前的Run
+type runtimeEntry struct {
+	vm       *goja.Runtime
+	tool     utils.UtilsTool
+	loop     *eventLoop
+	limiter  *limiter
+	modules  map[string]goja.Value // 本次执行已实例化的模块
+	loader   *moduleLoader         // 本次执行的用户模块加载器
+	console  *consoleCapture       // 本次执行的 console 输出收集，不需要执行报告时为nil
+	uses     int
+	ownNames goja.Callable
+	globals  map[string]goja.Value // 初始化完成时的全局变量，归还时据此复位
+}
+
+// 复位全局变量：删除脚本新增的全局变量，恢复被覆盖的内置对象
+// 内置对象及其原型在创建运行时已冻结，脚本无法修改
+func (e *runtimeEntry) reset() bool {
+	global := e.vm.GlobalObject()
+	names, err := e.ownNames(goja.Undefined(), global)
+	if err != nil {
+		return false
+	}
+	var keys []string
+	if err = e.vm.ExportTo(names, &keys); err != nil {
+		return false
+	}
+	for _, key := range keys {
+		original, ok := e.globals[key]
+		if !ok {
+			if global.Delete(key) != nil {
+				return false
+			}
+			continue
+		}
+		if !global.Get(key).SameAs(original) {
+			if global.Set(key, original) != nil {
+				return false
+			}
+		}
+	}
+	for key, original := range e.globals {
+		if global.Get(key) == nil {
+			if global.Set(key, original) != nil {
+				return false
+			}
+		}
+	}
+	e.vm.ClearInterrupt()
+	e.loop.reset()
+	e.loop.limiter = nil
+	e.limiter = nil
+	e.modules = make(map[string]goja.Value)
+	e.loader = nil
+	e.console = nil
+	e.tool = utils.UtilsTool{}
+	return true
+}
+
+func (p *jsrunStruct) newRuntime() *runtimeEntry {
+	newVm := goja.New()
+	entry := &runtimeEntry{vm: newVm, modules: make(map[string]goja.Value)}
+	entry.loop = newEventLoop(newVm)
+
+	newVm.Set("require", func(call goja.FunctionCall) goja.Value {
+		return p.requireModule(entry, ".", call.Argument(0).String())
+	})
+
+	// 注册 console 对象，使用 JS 对象而不是 Go map，脚本对其的修改可以被检测到
+	console := newVm.NewObject()
+	for _, level := range []string{"log", "info", "warn", "debug", "error"} {
+		logLevel := level
+		console.Set(logLevel, func(args ...interface{}) {
+			p.consoleLog(logLevel, entry, args...)
+		})
+	}
+	newVm.Set("console", console)
+
+	if err := entry.loop.install(); err != nil {
+		panic(err)
+	}
+	if err := p.installLengthGuard(entry); err != nil {
+		panic(err)
+	}
+	if err := disableCodeGeneration(newVm); err != nil {
+		panic(err)
+	}
+
+	ownNames, _ := newVm.RunProgram(getOwnPropertyNamesProg)
+	entry.ownNames, _ = goja.AssertFunction(ownNames)
+
+	global := newVm.GlobalObject()
+	names, _ := entry.ownNames(goja.Undefined(), global)
+	var keys []string
+	newVm.ExportTo(names, &keys)
+	entry.globals = make(map[string]goja.Value, len(keys))
+	for _, key := range keys {
+		entry.globals[key] = global.Get(key)
+	}
+	if err := hardenBuiltins(newVm, entry.globals); err != nil {
+		panic(err)
+	}
+	return entry
+}
+
+// 从池中取出运行时，池为空时新建
+func (p *jsrunStruct) getRuntime(utilsTool utils.UtilsTool) *runtimeEntry {
+	p.poolOnce.Do(p.initPool)
+
+	var entry *runtimeEntry
+	select {
+	case entry = <-p.runtimes:
+		atomic.AddInt64(&p.runtimeReused, 1)
+	default:
+		entry = p.newRuntime()
+		atomic.AddInt64(&p.runtimeCreated, 1)
+	}
+	entry.uses++
+	entry.tool = utilsTool
+	return entry
+}
+
+// 归还运行时，复位失败、复用次数过多或池已满时直接丢弃
+func (p *jsrunStruct) putRuntime(entry *runtimeEntry) {
+	maxUses := p.MaxRuntimeUses
+	if maxUses <= 0 {
+		maxUses = defaultMaxRuntimeUses
+	}
+	if entry.uses >= maxUses || !entry.reset() {
+		return
+	}
+	select {
+	case p.runtimes <- entry:
+	default:
+	}
+}
+
+func (p *jsrunStruct) initPool() {
+	size := p.RuntimePoolSize
+	if size <= 0 {
+		size = defaultRuntimePoolSize
+	}
+	p.runtimes = make(chan *runtimeEntry, size)
+}
+
+// 编译缓存的Key：脚本名 + 代码哈希
+func programKey(jsName, jscodeStr string) string {
+	return jsName + ":" + encrypt.MD5(jscodeStr)
+}
+
+func (p *jsrunStruct) loadProgram(key string) (*goja.Program, bool) {
+	p.programMutex.RLock()
+	prog, ok := p.programs[key]
+	p.programMutex.RUnlock()
+	if ok {
+		atomic.AddInt64(&p.programHits, 1)
+	} else {
+		atomic.AddInt64(&p.programMisses, 1)
+	}
+	return prog, ok
+}
+
+func (p *jsrunStruct) storeProgram(key string, prog *goja.Program) {
+	size := p.ProgramCacheSize
+	if size <= 0 {
+		size = defaultProgramCacheSize
+	}
+	p.programMutex.Lock()
+	defer p.programMutex.Unlock()
+	if p.programs == nil {
+		p.programs = make(map[string]*goja.Program)
+	}
+	// 缓存已满时随机淘汰一个
+	if _, ok := p.programs[key]; !ok && len(p.programs) >= size {
+		for k := range p.programs {
+			delete(p.programs, k)
+			break
+		}
+	}
+	p.programs[key] = prog
+}
+
+// Stats 返回运行时池与编译缓存的统计数据
+func (p *jsrunStruct) Stats() RunStats {
+	p.programMutex.RLock()
+	cached := len(p.programs)
+	p.programMutex.RUnlock()
+	return RunStats{
+		ProgramHits:    atomic.LoadInt64(&p.programHits),
+		ProgramMisses:  atomic.LoadInt64(&p.programMisses),
+		ProgramCached:  cached,
+		RuntimeCreated: atomic.LoadInt64(&p.runtimeCreated),
+		RuntimeReused:  atomic.LoadInt64(&p.runtimeReused),
+		RuntimeIdle:    len(p.runtimes),
+	}
+}
+
+// ClearPrograms 清空编译缓存
+func (p *jsrunStruct) ClearPrograms() {
+	p.programMutex.Lock()
+	p.programs = nil
+	p.sources = nil
+	p.programMutex.Unlock()
+}
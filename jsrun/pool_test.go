@@ -0,0 +1,138 @@
+package jsrun
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	utils "github.com/skyfox2000/nect-utils"
+	"github.com/skyfox2000/nect-utils/logger"
+)
+
+var testTool = utils.UtilsTool{Name: "test", Logger: logger.NewLogger()}
+
+// 编译并执行脚本
+func runScript(t *testing.T, name, code string, data map[string]interface{}, opts ...RunOption) (interface{}, error) {
+	t.Helper()
+	prog, err := JSRun.Compile(name, code, testTool)
+	if err != nil {
+		t.Fatalf("compile %s: %v", name, err)
+	}
+	ctx := context.Background()
+	timeout := 5
+	return JSRun.Run(&ctx, prog, testTool, data, map[string]*sync.RWMutex{}, false, 1, &timeout, opts...)
+}
+
+func TestBuiltinsNotShared(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify string
+		check  string
+		want   interface{}
+	}{
+		{"array prototype", `Array.prototype.map = function () { return "evil"; };`, `return [1].map(function (v) { return v + 1; })[0];`, int64(2)},
+		{"object prototype", `Object.prototype.polluted = 1;`, `return ({}).polluted === undefined;`, true},
+		{"json", `JSON.stringify = function () { return "evil"; };`, `return JSON.stringify(1);`, "1"},
+		{"constructor", `Array.from = function () { return "evil"; };`, `return Array.from([1]).length;`, int64(1)},
+		{"console", `console.log = function () {};`, `return typeof console.log === "function" && !console.log.toString().includes("{}");`, true},
+		{"iterator", `Object.getPrototypeOf([][Symbol.iterator]()).next = function () { return { done: true }; };`, `var n = 0; for (var v of [1, 2]) { n++; } return n;`, int64(2)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 修改失败或抛出异常都可以，只要不影响后续执行
+			_, _ = runScript(t, "modify", "try { "+tt.modify+" } catch (e) {}", nil)
+			for i := 0; i < 3; i++ {
+				result, err := runScript(t, "check", tt.check, nil)
+				if err != nil {
+					t.Fatalf("check: %v", err)
+				}
+				if result != tt.want {
+					t.Fatalf("result = %#v, want %#v", result, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBuiltinsOverride(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want interface{}
+	}{
+		{"error name", `class MyError extends Error { constructor(m) { super(m); this.name = "MyError"; } }
+			var e = new MyError("x"); e.message = "y"; return e.message === "y" && e.name === "MyError" && e instanceof Error;`, true},
+		{"toString", `var o = {}; o.toString = function () { return "custom"; }; return "" + o;`, "custom"},
+		{"constructor", `function A() {} A.prototype = Object.create(Object.prototype); A.prototype.constructor = A;
+			return new A().constructor === A;`, true},
+		{"own property", `var a = [1, 2]; a.push = function () { return "own"; }; return a.push() + [].push(1);`, "own1"},
+		{"builtin itself", `try { Object.prototype.toString = null; return "assigned"; } catch (e) { return e instanceof TypeError; }`, true},
+		{"async", `await new Promise(function (r) { setTimeout(r, 0); }); return [1, 2, 3].map(function (v) { return v * 2; }).join(",");`, "2,4,6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := runScript(t, "override", tt.code, nil)
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if result != tt.want {
+				t.Fatalf("result = %#v, want %#v", result, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuntimeReuse(t *testing.T) {
+	JSRun.poolOnce.Do(JSRun.initPool)
+	before := JSRun.Stats()
+	for i := 0; i < 5; i++ {
+		if _, err := runScript(t, "reuse", `globalThis.leak = 1; return typeof leak;`, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats := JSRun.Stats()
+	if stats.RuntimeReused-before.RuntimeReused < 4 {
+		t.Fatalf("runtime reused %d times, want at least 4", stats.RuntimeReused-before.RuntimeReused)
+	}
+	result, err := runScript(t, "reuse", `return typeof leak;`, nil)
+	if err != nil || !strings.EqualFold(result.(string), "undefined") {
+		t.Fatalf("global leaked into next run: %v %v", result, err)
+	}
+}
+
+func TestCodeGenerationDisabled(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+	}{
+		{"eval", `return eval("1");`},
+		{"global eval", `return globalThis["eval"]("1");`},
+		{"Function", `return Function("return 1")();`},
+		{"global Function", `return globalThis["Fun" + "ction"]("return 1")();`},
+		{"this Function", `return (function () { return this; })() || globalThis.Function("return 1")();`},
+		{"arrow constructor", `return (() => {}).constructor.constructor("return 1")();`},
+		{"async constructor", `return await Object.getPrototypeOf(async function () {}).constructor("return 1")();`},
+		{"generator constructor", `return Object.getPrototypeOf(function* () {}).constructor("yield 1")().next().value;`},
+		{"restore constructor", `try { Function.prototype.constructor = null; } catch (e) {} return (function () {}).constructor("return 1")();`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := `try { ` + tt.code + ` } catch (e) { return e instanceof TypeError || e instanceof ReferenceError ? "blocked" : String(e); }`
+			for i := 0; i < 2; i++ {
+				result, err := runScript(t, "codegen", code, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result != "blocked" {
+					t.Fatalf("result = %#v, want blocked", result)
+				}
+			}
+		})
+	}
+
+	result, err := runScript(t, "codegen", `var f = function () {}; return f instanceof Function && typeof Function === "function" && f.constructor === Function;`, nil)
+	if err != nil || result != true {
+		t.Fatalf("instanceof Function = %v, %v", result, err)
+	}
+}
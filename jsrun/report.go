@@ -0,0 +1,59 @@
+package jsrun
+
+import (
+	"sync"
+	"time"
+)
+
+// 单次执行最多记录的 console 输出行数
+const maxConsoleLines = 1000
+
+// ConsoleLine 脚本中 console.* 输出的一行
+type ConsoleLine struct {
+	Level   string    // log/info/warn/debug/error
+	Time    time.Time // 输出时间
+	Args    []string  // 逐个格式化后的参数
+	Message string    // 与日志内容一致的完整信息
+}
+
+// ExecutionReport 执行报告，通过 WithReport 获取
+type ExecutionReport struct {
+	Result    interface{}   // 执行结果
+	Error     error         // 执行错误
+	Console   []ConsoleLine // console 输出
+	Truncated bool          // console 输出超过上限时为 true
+	Duration  time.Duration // 脚本执行时长，不含等待协程池的时间
+	PoolWait  time.Duration // 等待协程池分配 worker 的时长
+}
+
+// consoleCapture 收集执行期间的 console 输出
+// 超时后脚本可能仍在退出中，close 之后的输出不再记录
+type consoleCapture struct {
+	mutex     sync.Mutex
+	lines     []ConsoleLine
+	truncated bool
+	closed    bool
+}
+
+func (c *consoleCapture) add(line ConsoleLine) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		return
+	}
+	if len(c.lines) >= maxConsoleLines {
+		c.truncated = true
+		return
+	}
+	c.lines = append(c.lines, line)
+}
+
+func (c *consoleCapture) close() ([]ConsoleLine, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.closed = true
+	return c.lines, c.truncated
+}
@@ -0,0 +1,84 @@
+package jsrun
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExecutionReport(t *testing.T) {
+	report := &ExecutionReport{}
+	before := time.Now()
+	result, err := runScript(t, "report", `console.log("hello", 1, true);
+		console.warn("careful");
+		await new Promise(function (r) { setTimeout(r, 20); });
+		return 42;`, nil, WithReport(report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != int64(42) || report.Result != result || report.Error != nil {
+		t.Fatalf("report = %+v, want result 42", report)
+	}
+	if len(report.Console) != 2 || report.Truncated {
+		t.Fatalf("console = %+v, want 2 lines", report.Console)
+	}
+	first := report.Console[0]
+	if first.Level != "log" || !reflect.DeepEqual(first.Args, []string{"hello", "1", "true"}) {
+		t.Fatalf("console[0] = %+v", first)
+	}
+	if first.Message != "hello 1 true" {
+		t.Fatalf("console[0].Message = %q, want %q", first.Message, "hello 1 true")
+	}
+	if first.Time.Before(before) || first.Time.After(time.Now()) {
+		t.Fatalf("console[0].Time = %v", first.Time)
+	}
+	if second := report.Console[1]; second.Level != "warn" || !reflect.DeepEqual(second.Args, []string{"careful"}) {
+		t.Fatalf("console[1] = %+v", second)
+	}
+	if report.Duration < 20*time.Millisecond || report.PoolWait < 0 {
+		t.Fatalf("duration = %v, pool wait = %v", report.Duration, report.PoolWait)
+	}
+}
+
+func TestExecutionReportError(t *testing.T) {
+	report := &ExecutionReport{}
+	_, err := runScript(t, "report error", `console.error("before"); throw new TypeError("boom");`, nil, WithReport(report))
+	if err == nil || report.Error != err || report.Result != nil {
+		t.Fatalf("report = %+v, want the Run error", report)
+	}
+	if len(report.Console) != 1 || report.Console[0].Level != "error" {
+		t.Fatalf("console = %+v, want the line printed before the error", report.Console)
+	}
+}
+
+func TestExecutionReportTruncated(t *testing.T) {
+	report := &ExecutionReport{}
+	_, err := runScript(t, "report truncated", `for (var i = 0; i <= 1000; i++) { console.debug("line", i); }`, nil, WithReport(report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Console) != maxConsoleLines || !report.Truncated {
+		t.Fatalf("console lines = %d, truncated = %v", len(report.Console), report.Truncated)
+	}
+}
+
+func TestConsoleMessage(t *testing.T) {
+	tests := []struct {
+		args string
+		want string
+	}{
+		{`1, "x"`, "1 x"},
+		{`"a", "b"`, "a b"},
+		{`true`, "true"},
+		{``, ""},
+	}
+	for _, tt := range tests {
+		report := &ExecutionReport{}
+		if _, err := runScript(t, "console", "console.info("+tt.args+");", nil, WithReport(report)); err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Console) != 1 || report.Console[0].Message != tt.want {
+			t.Fatalf("console.info(%s) = %+v, want message %q", tt.args, report.Console, tt.want)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package jsrun
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/file"
+	"github.com/dop251/goja/parser"
+	utils "github.com/skyfox2000/nect-utils"
+	"github.com/skyfox2000/nect-utils/jsmodule"
+)
+
+// 诊断级别
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// 诊断规则
+const (
+	RuleSyntax          = "syntax"          // 语法错误
+	RuleUndeclaredData  = "undeclaredData"  // 使用了数据结构中未声明的 $ 变量
+	RuleUnknownModule   = "unknownModule"   // require 了不存在或未允许的模块
+	RuleDynamicRequire  = "dynamicRequire"  // require 的参数不是字符串常量，无法检查
+	RuleForbiddenGlobal = "forbiddenGlobal" // 使用了禁止的全局对象，如 eval、Function
+	RuleUnreachable     = "unreachable"     // return/throw/break/continue 之后的代码不会执行
+)
+
+// 禁止脚本使用的全局对象，静态检查只能发现直接引用，不是安全边界；
+// 运行时已删除 eval 并禁用 Function 构造函数（见 disableCodeGeneration），间接访问同样会抛出异常
+var forbiddenGlobals = []string{"eval", "Function"}
+
+// Diagnostic 静态检查发现的问题，行列号对应原始脚本
+type Diagnostic struct {
+	Severity string
+	Rule     string
+	Message  string
+	Line     int
+	Column   int
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d %s [%s] %s", d.Line, d.Column, d.Severity, d.Rule, d.Message)
+}
+
+// Validate 静态检查脚本，返回按位置排序的诊断信息，不执行脚本
+// schema 为将要注入的数据（只检查 key），为 nil 时不检查 $ 变量；opts 中的 WithModuleFS 用于检查用户模块
+func (p *jsrunStruct) Validate(jsName, jscodeStr string, utilsTool utils.UtilsTool, schema map[string]interface{}, opts ...RunOption) []Diagnostic {
+	cfg := p.newRunConfig(opts)
+	prepareCode := fmt.Sprintf("\"use strict\";\n(async function(){\n%s\n})();", jscodeStr)
+
+	v := &validator{
+		schema:   schema,
+		tool:     utilsTool,
+		loader:   newModuleLoader(cfg.moduleFS),
+		declared: make(map[string]bool),
+	}
+
+	program, err := parser.ParseFile(nil, jsName+".js", prepareCode, 0, parser.WithDisableSourceMaps)
+	if err != nil {
+		var errList parser.ErrorList
+		if errors.As(err, &errList) {
+			// 解析器遇到错误后继续解析，会在包装代码的结尾重复报告；只保留脚本内的错误，
+			// 都在包装代码中时（如缺少 }）报告一次，位置为脚本末尾
+			lastLine := strings.Count(jscodeStr, "\n") + 1
+			for _, e := range errList {
+				pos := e.Position
+				if pos.Line-wrapperLines > lastLine {
+					if len(v.diagnostics) > 0 {
+						continue
+					}
+					pos.Line = lastLine + wrapperLines
+					pos.Column = len(jscodeStr) - strings.LastIndex(jscodeStr, "\n")
+				}
+				v.add(SeverityError, RuleSyntax, e.Message, pos)
+			}
+		} else {
+			v.diagnostics = append(v.diagnostics, Diagnostic{Severity: SeverityError, Rule: RuleSyntax, Message: err.Error()})
+		}
+		return v.result()
+	}
+	v.file = program.File
+
+	// 先收集脚本中声明的名称，脚本自己声明的 $ 变量与 require 不做检查
+	walkAST(reflect.ValueOf(program), v.collect)
+	walkAST(reflect.ValueOf(program), v.check)
+	return v.result()
+}
+
+type validator struct {
+	schema      map[string]interface{}
+	tool        utils.UtilsTool
+	loader      *moduleLoader
+	file        *file.File
+	declared    map[string]bool
+	diagnostics []Diagnostic
+}
+
+func (v *validator) add(severity, rule, message string, pos file.Position) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{
+		Severity: severity,
+		Rule:     rule,
+		Message:  message,
+		Line:     pos.Line - wrapperLines,
+		Column:   pos.Column,
+	})
+}
+
+func (v *validator) addAt(severity, rule, message string, idx file.Idx) {
+	v.add(severity, rule, message, v.file.Position(int(idx)-v.file.Base()))
+}
+
+func (v *validator) result() []Diagnostic {
+	sort.SliceStable(v.diagnostics, func(i, j int) bool {
+		a, b := v.diagnostics[i], v.diagnostics[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return v.diagnostics
+}
+
+func (v *validator) collect(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.Binding:
+		if id, ok := n.Target.(*ast.Identifier); ok {
+			v.declared[id.Name.String()] = true
+		}
+	case *ast.CatchStatement:
+		if id, ok := n.Parameter.(*ast.Identifier); ok {
+			v.declared[id.Name.String()] = true
+		}
+	case *ast.FunctionLiteral:
+		if n.Name != nil {
+			v.declared[n.Name.Name.String()] = true
+		}
+	case *ast.ClassLiteral:
+		if n.Name != nil {
+			v.declared[n.Name.Name.String()] = true
+		}
+	}
+	return true
+}
+
+func (v *validator) check(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.Identifier:
+		v.checkIdentifier(n)
+	case *ast.CallExpression:
+		v.checkRequire(n)
+	case *ast.Program:
+		v.checkReachable(n.Body)
+	case *ast.BlockStatement:
+		v.checkReachable(n.List)
+	case *ast.CaseStatement:
+		v.checkReachable(n.Consequent)
+	case *ast.LabelledStatement:
+		// 标签名不是变量引用
+		walkAST(reflect.ValueOf(n.Statement), v.check)
+		return false
+	case *ast.BranchStatement:
+		return false
+	}
+	return true
+}
+
+func (v *validator) checkIdentifier(id *ast.Identifier) {
+	name := id.Name.String()
+	// 不区分作用域，禁止的全局对象即使被同名局部变量覆盖也报告，避免借此绕过检查
+	for _, forbidden := range forbiddenGlobals {
+		if name == forbidden {
+			v.addAt(SeverityError, RuleForbiddenGlobal, "use of "+name+" is not allowed", id.Idx)
+			return
+		}
+	}
+	if v.schema != nil && !v.declared[name] && strings.HasPrefix(name, "$") && len(name) > 1 {
+		if _, ok := v.schema[name[1:]]; !ok {
+			v.addAt(SeverityError, RuleUndeclaredData, name+" is not declared in data schema", id.Idx)
+		}
+	}
+}
+
+func (v *validator) checkRequire(call *ast.CallExpression) {
+	callee, ok := call.Callee.(*ast.Identifier)
+	if !ok || callee.Name != "require" || v.declared["require"] {
+		return
+	}
+	if len(call.ArgumentList) == 0 {
+		v.addAt(SeverityError, RuleUnknownModule, "require needs a module name", call.Idx0())
+		return
+	}
+	arg, ok := call.ArgumentList[0].(*ast.StringLiteral)
+	if !ok {
+		v.addAt(SeverityWarning, RuleDynamicRequire, "require argument is not a string literal, module cannot be checked", call.ArgumentList[0].Idx0())
+		return
+	}
+
+	spec := arg.Value.String()
+	isPath := strings.HasPrefix(spec, "./") || strings.HasPrefix(spec, "../") || strings.HasPrefix(spec, "/")
+	var err error
+	if !isPath {
+		if _, _, err = jsmodule.Resolve(spec, v.tool.Modules); err == nil {
+			return
+		}
+	}
+	if v.loader.fsys != nil {
+		_, fsErr := v.loader.resolve(".", spec, isPath)
+		if fsErr == nil {
+			return
+		}
+		if isPath {
+			err = fsErr
+		}
+	} else if isPath {
+		err = errors.New("require module not found: " + spec + ", module source is not configured")
+	}
+	v.addAt(SeverityError, RuleUnknownModule, err.Error(), arg.Idx)
+}
+
+// 同一语句列表中，跳转语句之后的语句不会执行；函数声明会被提升，不算在内
+func (v *validator) checkReachable(list []ast.Statement) {
+	for i, stmt := range list {
+		switch stmt.(type) {
+		case *ast.ReturnStatement, *ast.ThrowStatement, *ast.BranchStatement:
+		default:
+			continue
+		}
+		for _, next := range list[i+1:] {
+			switch next.(type) {
+			case *ast.FunctionDeclaration, *ast.EmptyStatement:
+				continue
+			}
+			v.addAt(SeverityWarning, RuleUnreachable, "unreachable code", next.Idx0())
+			break
+		}
+		return
+	}
+}
+
+var astNodeType = reflect.TypeOf((*ast.Node)(nil)).Elem()
+var astFileType = reflect.TypeOf((*file.File)(nil))
+
+// 深度优先遍历 AST，visit 返回 false 时不再遍历该节点的子节点
+func walkAST(value reflect.Value, visit func(ast.Node) bool) {
+	switch value.Kind() {
+	case reflect.Interface:
+		if !value.IsNil() {
+			walkAST(value.Elem(), visit)
+		}
+	case reflect.Ptr:
+		if value.IsNil() || value.Type() == astFileType {
+			return
+		}
+		if value.Type().Implements(astNodeType) {
+			if !visit(value.Interface().(ast.Node)) {
+				return
+			}
+		}
+		walkAST(value.Elem(), visit)
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			// DeclarationList 与函数体中的声明重复
+			if !field.IsExported() || field.Name == "DeclarationList" {
+				continue
+			}
+			walkAST(value.Field(i), visit)
+		}
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			walkAST(value.Index(i), visit)
+		}
+	}
+}
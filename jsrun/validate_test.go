@@ -0,0 +1,64 @@
+package jsrun
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestValidate(t *testing.T) {
+	schema := map[string]interface{}{"order": nil}
+	tests := []struct {
+		name string
+		code string
+		want []string // Diagnostic.String()，unknownModule 只比较到模块名
+	}{
+		{"clean", "var total = $order.price * 2;\nreturn total;", nil},
+		{"syntax error", "var a = 1;\nvar b = ;", []string{"2:9 error [syntax] Unexpected token ;"}},
+		{"unclosed block", "if (a) {\n  return 1;", []string{"2:12 error [syntax] Unexpected token )"}},
+		{"undeclared data", "var a = 1;\nreturn $order.id + $user.name;",
+			[]string{"2:20 error [undeclaredData] $user is not declared in data schema"}},
+		{"declared by script", "var $tmp = 1;\nreturn $tmp + $order.id;", nil},
+		{"modules", "var m = require(\"nope\");\nvar n = require(name);\nvar j = require(\"JSON\");", []string{
+			"1:17 error [unknownModule] require module not found: nope",
+			"2:17 warning [dynamicRequire] require argument is not a string literal, module cannot be checked",
+		}},
+		{"user module", "var u = require(\"./util\");\nvar m = require(\"./missing\");",
+			[]string{"2:17 error [unknownModule] require module not found: ./missing"}},
+		{"forbidden globals", "eval(\"1\");\nnew Function(\"return 1\");", []string{
+			"1:1 error [forbiddenGlobal] use of eval is not allowed",
+			"2:5 error [forbiddenGlobal] use of Function is not allowed",
+		}},
+		{"unreachable", "if (true) {\n  return 1;\n  var x = 2;\n}", []string{"3:3 warning [unreachable] unreachable code"}},
+	}
+	fsys := fstest.MapFS{"util.js": {Data: []byte("module.exports = {};")}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, d := range JSRun.Validate(tt.name, tt.code, testTool, schema, WithModuleFS(fsys)) {
+				s := d.String()
+				if d.Rule == RuleUnknownModule {
+					s, _, _ = strings.Cut(s, ", available modules")
+				}
+				got = append(got, s)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// schema 为 nil 时不检查 $ 变量，白名单外的模块报告为 unknownModule
+func TestValidateOptions(t *testing.T) {
+	if got := JSRun.Validate("nil schema", "return $anything;", testTool, nil); len(got) != 0 {
+		t.Fatalf("Validate() = %v, want no diagnostics", got)
+	}
+	tool := testTool
+	tool.Modules = []string{"JSON"}
+	got := JSRun.Validate("allowlist", `require("dayjs");`, tool, nil)
+	if len(got) != 1 || got[0].Rule != RuleUnknownModule || !strings.HasSuffix(got[0].Message, "available modules: JSON") {
+		t.Fatalf("Validate() = %v, want unknownModule listing JSON", got)
+	}
+}
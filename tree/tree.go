@@ -0,0 +1,376 @@
+package tree
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Tree 对应的结构体
+var Tree = &treeStruct{}
+
+type treeStruct struct{}
+
+// ErrCycle 树中存在循环引用
+var ErrCycle = errors.New("tree contains a cycle")
+
+// Issues 列表数据的问题，id 均为原始值
+type Issues struct {
+	Duplicates []interface{}   // 重复的 id
+	Orphans    []interface{}   // 父节点不存在的节点（父 id 为空的根节点除外）
+	Cycles     [][]interface{} // 形成环的节点，每个环按父子顺序排列
+}
+
+// 是否没有问题
+func (i *Issues) Empty() bool {
+	return len(i.Duplicates) == 0 && len(i.Orphans) == 0 && len(i.Cycles) == 0
+}
+
+func (i *Issues) Error() string {
+	var messages []string
+	if len(i.Duplicates) > 0 {
+		messages = append(messages, fmt.Sprintf("duplicate ids %v", i.Duplicates))
+	}
+	if len(i.Orphans) > 0 {
+		messages = append(messages, fmt.Sprintf("orphan nodes %v", i.Orphans))
+	}
+	for _, cycle := range i.Cycles {
+		messages = append(messages, fmt.Sprintf("cycle %v", cycle))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// WalkFunc 遍历回调，depth 从0开始，path 为从根节点到当前节点（含）的节点，返回 false 时停止遍历
+type WalkFunc func(node map[string]interface{}, depth int, path []interface{}) bool
+
+// PredicateFunc 节点是否匹配
+type PredicateFunc func(node map[string]interface{}) bool
+
+// AggregateFunc 子树聚合，results 为子节点的聚合结果，按子节点顺序排列
+type AggregateFunc func(node map[string]interface{}, results []interface{}) interface{}
+
+// 检查列表中的重复 id、孤儿节点和循环引用
+// 父 id 为 nil、空字符串或 rootIDs 中的值（如 "-"、0）的节点为根节点；父 id 指向不存在的节点时为孤儿节点
+func (p *treeStruct) Check(list []interface{}, idKey, parentKey string, rootIDs ...interface{}) (*Issues, error) {
+	nodes, err := toNodes(list)
+	if err != nil {
+		return nil, err
+	}
+	issues := &Issues{}
+	index := make(map[string]map[string]interface{}, len(nodes))
+	for _, node := range nodes {
+		key := idOf(node[idKey])
+		if _, exists := index[key]; exists {
+			issues.Duplicates = append(issues.Duplicates, node[idKey])
+			continue
+		}
+		index[key] = node
+	}
+
+	for _, node := range nodes {
+		parent := node[parentKey]
+		if isRootID(parent, rootIDs) {
+			continue
+		}
+		if _, ok := index[idOf(parent)]; !ok {
+			issues.Orphans = append(issues.Orphans, node[idKey])
+		}
+	}
+	issues.Cycles = findCycles(nodes, index, idKey, parentKey, rootIDs)
+	return issues, nil
+}
+
+// 沿父节点向上查找环，每个环只报告一次
+func findCycles(nodes []map[string]interface{}, index map[string]map[string]interface{}, idKey, parentKey string, rootIDs []interface{}) [][]interface{} {
+	var cycles [][]interface{}
+	// 0 未访问，1 当前路径上，2 已确认无环
+	state := make(map[string]int, len(nodes))
+	for _, node := range nodes {
+		var chain []string
+		key := idOf(node[idKey])
+		closed := false
+		for state[key] == 0 {
+			state[key] = 1
+			chain = append(chain, key)
+			current := index[key]
+			if isRootID(current[parentKey], rootIDs) || index[idOf(current[parentKey])] == nil {
+				break
+			}
+			key = idOf(current[parentKey])
+			closed = state[key] == 1
+		}
+		if closed {
+			// 从 key 第一次出现的位置开始为环，按父到子的顺序输出
+			start := 0
+			for chain[start] != key {
+				start++
+			}
+			var cycle []interface{}
+			for i := len(chain) - 1; i >= start; i-- {
+				cycle = append(cycle, index[chain[i]][idKey])
+			}
+			cycles = append(cycles, cycle)
+		}
+		for _, k := range chain {
+			state[k] = 2
+		}
+	}
+	return cycles
+}
+
+// 将列表构建为树，返回根节点列表，节点为原数据的浅拷贝，子节点放在 childrenKey 中
+// 根节点的判断同 Check，孤儿节点也作为根节点；存在重复 id 或循环引用时返回 *Issues 错误
+func (p *treeStruct) BuildTree(list []interface{}, idKey, parentKey, childrenKey string, rootIDs ...interface{}) ([]interface{}, error) {
+	issues, err := p.Check(list, idKey, parentKey, rootIDs...)
+	if err != nil {
+		return nil, err
+	}
+	if len(issues.Duplicates) > 0 || len(issues.Cycles) > 0 {
+		issues.Orphans = nil
+		return nil, issues
+	}
+
+	nodes, _ := toNodes(list)
+	copies := make([]map[string]interface{}, len(nodes))
+	index := make(map[string]map[string]interface{}, len(nodes))
+	for i, node := range nodes {
+		copied := make(map[string]interface{}, len(node)+1)
+		for k, v := range node {
+			copied[k] = v
+		}
+		copied[childrenKey] = []interface{}{}
+		copies[i] = copied
+		index[idOf(node[idKey])] = copied
+	}
+
+	roots := []interface{}{}
+	for _, node := range copies {
+		parent, ok := index[idOf(node[parentKey])]
+		if isRootID(node[parentKey], rootIDs) || !ok {
+			roots = append(roots, node)
+			continue
+		}
+		parent[childrenKey] = append(parent[childrenKey].([]interface{}), node)
+	}
+	return roots, nil
+}
+
+// 将树展开为列表（先序遍历），节点为浅拷贝，不包含 childrenKey
+func (p *treeStruct) Flatten(tree []interface{}, childrenKey string) ([]interface{}, error) {
+	result := []interface{}{}
+	err := p.Walk(tree, childrenKey, func(node map[string]interface{}, depth int, path []interface{}) bool {
+		copied := make(map[string]interface{}, len(node))
+		for k, v := range node {
+			if k != childrenKey {
+				copied[k] = v
+			}
+		}
+		result = append(result, copied)
+		return true
+	})
+	return result, err
+}
+
+// 先序遍历树，节点被重复引用（循环）时返回 ErrCycle
+func (p *treeStruct) Walk(tree []interface{}, childrenKey string, fn WalkFunc) error {
+	_, err := walk(tree, childrenKey, 0, nil, map[uintptr]bool{}, fn)
+	return err
+}
+
+// visiting 记录当前路径上节点 map 的地址，返回 false 表示停止遍历
+func walk(nodes []interface{}, childrenKey string, depth int, path []interface{},
+	visiting map[uintptr]bool, fn WalkFunc) (bool, error) {
+	for _, item := range nodes {
+		node, ok := item.(map[string]interface{})
+		if !ok {
+			return false, fmt.Errorf("tree node must be an object, got %T", item)
+		}
+		ptr := reflect.ValueOf(node).Pointer()
+		if visiting[ptr] {
+			return false, ErrCycle
+		}
+		visiting[ptr] = true
+		nodePath := append(append(make([]interface{}, 0, len(path)+1), path...), node)
+		if !fn(node, depth, nodePath) {
+			return false, nil
+		}
+		children, _ := childrenOf(node, childrenKey)
+		if goOn, err := walk(children, childrenKey, depth+1, nodePath, visiting, fn); !goOn || err != nil {
+			return false, err
+		}
+		delete(visiting, ptr)
+	}
+	return true, nil
+}
+
+// 查找第一个匹配的节点，返回从根节点到该节点的路径（最后一个为匹配的节点），未找到时返回 nil
+func (p *treeStruct) Find(tree []interface{}, childrenKey string, predicate PredicateFunc) ([]interface{}, error) {
+	var found []interface{}
+	err := p.Walk(tree, childrenKey, func(node map[string]interface{}, depth int, path []interface{}) bool {
+		if predicate(node) {
+			found = path
+			return false
+		}
+		return true
+	})
+	return found, err
+}
+
+// 过滤树，保留匹配的节点及其所有祖先节点，返回新的树，不修改原数据
+// 匹配节点的子节点同样按条件过滤
+func (p *treeStruct) Filter(tree []interface{}, childrenKey string, predicate PredicateFunc) ([]interface{}, error) {
+	if err := p.Walk(tree, childrenKey, func(map[string]interface{}, int, []interface{}) bool { return true }); err != nil {
+		return nil, err
+	}
+	return filterNodes(tree, childrenKey, predicate), nil
+}
+
+func filterNodes(nodes []interface{}, childrenKey string, predicate PredicateFunc) []interface{} {
+	result := []interface{}{}
+	for _, item := range nodes {
+		node := item.(map[string]interface{})
+		children, _ := childrenOf(node, childrenKey)
+		filtered := filterNodes(children, childrenKey, predicate)
+		if len(filtered) == 0 && !predicate(node) {
+			continue
+		}
+		copied := make(map[string]interface{}, len(node))
+		for k, v := range node {
+			copied[k] = v
+		}
+		copied[childrenKey] = filtered
+		result = append(result, copied)
+	}
+	return result
+}
+
+// 返回从根节点到指定 id 节点的路径，未找到时返回 nil
+func (p *treeStruct) PathTo(tree []interface{}, id interface{}, idKey, childrenKey string) ([]interface{}, error) {
+	key := idOf(id)
+	return p.Find(tree, childrenKey, func(node map[string]interface{}) bool {
+		return idOf(node[idKey]) == key
+	})
+}
+
+// 自底向上聚合子树，每个节点的结果保存在 resultKey 中，返回新的树，不修改原数据
+func (p *treeStruct) Aggregate(tree []interface{}, childrenKey, resultKey string, fn AggregateFunc) ([]interface{}, error) {
+	if err := p.Walk(tree, childrenKey, func(map[string]interface{}, int, []interface{}) bool { return true }); err != nil {
+		return nil, err
+	}
+	result, _ := aggregateNodes(tree, childrenKey, resultKey, fn)
+	return result, nil
+}
+
+func aggregateNodes(nodes []interface{}, childrenKey, resultKey string, fn AggregateFunc) ([]interface{}, []interface{}) {
+	copies := make([]interface{}, len(nodes))
+	results := make([]interface{}, len(nodes))
+	for i, item := range nodes {
+		node := item.(map[string]interface{})
+		copied := make(map[string]interface{}, len(node)+1)
+		for k, v := range node {
+			copied[k] = v
+		}
+		children, _ := childrenOf(node, childrenKey)
+		childCopies, childResults := aggregateNodes(children, childrenKey, resultKey, fn)
+		if _, ok := node[childrenKey]; ok {
+			copied[childrenKey] = childCopies
+		}
+		results[i] = fn(copied, childResults)
+		copied[resultKey] = results[i]
+		copies[i] = copied
+	}
+	return copies, results
+}
+
+// 汇总子树中 valueKey 的数值（含节点自身），结果保存在 resultKey 中
+func (p *treeStruct) Sum(tree []interface{}, childrenKey, valueKey, resultKey string) ([]interface{}, error) {
+	return p.Aggregate(tree, childrenKey, resultKey, func(node map[string]interface{}, results []interface{}) interface{} {
+		total := toFloat(node[valueKey])
+		for _, result := range results {
+			total += result.(float64)
+		}
+		return total
+	})
+}
+
+// 统计子树的节点数（含节点自身），结果保存在 resultKey 中
+func (p *treeStruct) Count(tree []interface{}, childrenKey, resultKey string) ([]interface{}, error) {
+	return p.Aggregate(tree, childrenKey, resultKey, func(node map[string]interface{}, results []interface{}) interface{} {
+		count := 1
+		for _, result := range results {
+			count += result.(int)
+		}
+		return count
+	})
+}
+
+// 统一列表格式，支持 []interface{} 与 []map[string]interface{}
+func toNodes(list []interface{}) ([]map[string]interface{}, error) {
+	nodes := make([]map[string]interface{}, len(list))
+	for i, item := range list {
+		node, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("tree node %d must be an object, got %T", i, item)
+		}
+		nodes[i] = node
+	}
+	return nodes, nil
+}
+
+func childrenOf(node map[string]interface{}, childrenKey string) ([]interface{}, bool) {
+	switch children := node[childrenKey].(type) {
+	case []interface{}:
+		return children, true
+	case []map[string]interface{}:
+		result := make([]interface{}, len(children))
+		for i, child := range children {
+			result[i] = child
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+// id 统一转换为字符串比较，数字 1 与字符串 "1" 视为相同
+func idOf(id interface{}) string {
+	switch v := id.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(id)
+}
+
+func isRootID(id interface{}, rootIDs []interface{}) bool {
+	if id == nil || id == "" {
+		return true
+	}
+	key := idOf(id)
+	for _, rootID := range rootIDs {
+		if idOf(rootID) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// 数值转换为 float64，支持所有整数与浮点数类型（包括以其为底层类型的自定义类型）以及 json.Number，其他值为0
+func toFloat(value interface{}) float64 {
+	if n, ok := value.(interface{ Float64() (float64, error) }); ok {
+		f, _ := n.Float64()
+		return f
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	}
+	return 0
+}
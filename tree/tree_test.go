@@ -0,0 +1,205 @@
+package tree
+
+import (
+	stdjson "encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func node(id, parent interface{}, value float64) map[string]interface{} {
+	return map[string]interface{}{"id": id, "pid": parent, "value": value}
+}
+
+func ids(nodes []interface{}) []interface{} {
+	result := make([]interface{}, len(nodes))
+	for i, item := range nodes {
+		result[i] = item.(map[string]interface{})["id"]
+	}
+	return result
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    []interface{}
+		rootIDs []interface{}
+		want    Issues
+	}{
+		{"valid", []interface{}{node(1, nil, 0), node(2, 1, 0), node(3, "1", 0)}, nil, Issues{}},
+		{"root id", []interface{}{node(1, "-", 0), node(2, 1, 0)}, []interface{}{"-"}, Issues{}},
+		{"duplicate", []interface{}{node(1, nil, 0), node(1, nil, 0)}, nil, Issues{Duplicates: []interface{}{1}}},
+		{"orphan", []interface{}{node(1, nil, 0), node(2, 9, 0)}, nil, Issues{Orphans: []interface{}{2}}},
+		{"cycle", []interface{}{node(1, nil, 0), node(2, 4, 0), node(3, 2, 0), node(4, 3, 0)}, nil,
+			Issues{Cycles: [][]interface{}{{3, 4, 2}}}},
+		{"self parent", []interface{}{node(1, 1, 0)}, nil, Issues{Cycles: [][]interface{}{{1}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := Tree.Check(tt.list, "id", "pid", tt.rootIDs...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*issues, tt.want) {
+				t.Fatalf("issues = %+v, want %+v", *issues, tt.want)
+			}
+			if issues.Empty() != tt.want.Empty() {
+				t.Fatalf("Empty() = %v", issues.Empty())
+			}
+		})
+	}
+	if _, err := Tree.Check([]interface{}{1}, "id", "pid"); err == nil {
+		t.Fatal("non-object node returned no error")
+	}
+}
+
+func TestBuildTree(t *testing.T) {
+	list := []interface{}{node(1, nil, 1), node(2, 1, 2), node(3, 1, 3), node(4, 2, 4), node(5, 9, 5)}
+	tree, err := Tree.BuildTree(list, "id", "pid", "children")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(tree); !reflect.DeepEqual(got, []interface{}{1, 5}) {
+		t.Fatalf("roots = %v, want [1 5]", got)
+	}
+	children := tree[0].(map[string]interface{})["children"].([]interface{})
+	if got := ids(children); !reflect.DeepEqual(got, []interface{}{2, 3}) {
+		t.Fatalf("children = %v, want [2 3]", got)
+	}
+	if _, ok := list[0].(map[string]interface{})["children"]; ok {
+		t.Fatal("BuildTree modified the list")
+	}
+
+	flat, err := Tree.Flatten(tree, "children")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(flat); !reflect.DeepEqual(got, []interface{}{1, 2, 4, 3, 5}) {
+		t.Fatalf("flatten = %v", got)
+	}
+
+	_, err = Tree.BuildTree([]interface{}{node(1, 2, 0), node(2, 1, 0)}, "id", "pid", "children")
+	var issues *Issues
+	if !errors.As(err, &issues) || len(issues.Cycles) != 1 {
+		t.Fatalf("err = %v, want cycle issues", err)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	tree, _ := Tree.BuildTree([]interface{}{node(1, nil, 0), node(2, 1, 0), node(3, 2, 0), node(4, nil, 0)}, "id", "pid", "children")
+	tests := []struct {
+		name      string
+		stopAt    interface{}
+		wantIDs   []interface{}
+		wantDepth []int
+	}{
+		{"all", nil, []interface{}{1, 2, 3, 4}, []int{0, 1, 2, 0}},
+		{"stop", 3, []interface{}{1, 2, 3}, []int{0, 1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotIDs []interface{}
+			var gotDepth []int
+			err := Tree.Walk(tree, "children", func(n map[string]interface{}, depth int, path []interface{}) bool {
+				if len(path) != depth+1 || path[depth].(map[string]interface{})["id"] != n["id"] {
+					t.Fatalf("path %v does not end with node %v", ids(path), n["id"])
+				}
+				gotIDs = append(gotIDs, n["id"])
+				gotDepth = append(gotDepth, depth)
+				return n["id"] != tt.stopAt
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotIDs, tt.wantIDs) || !reflect.DeepEqual(gotDepth, tt.wantDepth) {
+				t.Fatalf("walk = %v %v, want %v %v", gotIDs, gotDepth, tt.wantIDs, tt.wantDepth)
+			}
+		})
+	}
+
+	loop := map[string]interface{}{"id": 1}
+	loop["children"] = []interface{}{loop}
+	if err := Tree.Walk([]interface{}{loop}, "children", func(map[string]interface{}, int, []interface{}) bool { return true }); !errors.Is(err, ErrCycle) {
+		t.Fatalf("err = %v, want ErrCycle", err)
+	}
+
+	path, err := Tree.PathTo(tree, "3", "id", "children")
+	if err != nil || !reflect.DeepEqual(ids(path), []interface{}{1, 2, 3}) {
+		t.Fatalf("PathTo = %v, %v", path, err)
+	}
+	filtered, err := Tree.Filter(tree, "children", func(n map[string]interface{}) bool { return n["id"] == 3 })
+	if err != nil || !reflect.DeepEqual(ids(filtered), []interface{}{1}) {
+		t.Fatalf("Filter = %v, %v", filtered, err)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	list := []interface{}{node(1, nil, 1), node(2, 1, 2), node(3, 1, 3), node(4, 2, 4)}
+	list = append(list,
+		map[string]interface{}{"id": 5, "pid": 4, "value": 5.0},
+		map[string]interface{}{"id": 6, "pid": 4, "value": "x"})
+	tree, _ := Tree.BuildTree(list, "id", "pid", "children")
+
+	tests := []struct {
+		name string
+		run  func() ([]interface{}, error)
+		want map[interface{}]interface{}
+	}{
+		{"sum", func() ([]interface{}, error) { return Tree.Sum(tree, "children", "value", "total") },
+			map[interface{}]interface{}{1: 15.0, 2: 11.0, 3: 3.0, 4: 9.0, 5: 5.0, 6: 0.0}},
+		{"count", func() ([]interface{}, error) { return Tree.Count(tree, "children", "total") },
+			map[interface{}]interface{}{1: 6, 2: 4, 3: 1, 4: 3, 5: 1, 6: 1}},
+		{"depth", func() ([]interface{}, error) {
+			return Tree.Aggregate(tree, "children", "total", func(n map[string]interface{}, results []interface{}) interface{} {
+				depth := 1
+				for _, r := range results {
+					if r.(int)+1 > depth {
+						depth = r.(int) + 1
+					}
+				}
+				return depth
+			})
+		}, map[interface{}]interface{}{1: 4, 2: 3, 3: 1, 4: 2, 5: 1, 6: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.run()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[interface{}]interface{}{}
+			Tree.Walk(result, "children", func(n map[string]interface{}, depth int, path []interface{}) bool {
+				got[n["id"]] = n["total"]
+				return true
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("results = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if _, ok := tree[0].(map[string]interface{})["total"]; ok {
+		t.Fatal("Aggregate modified the tree")
+	}
+}
+
+func TestSumNumericKinds(t *testing.T) {
+	type amount int16
+	tests := []struct {
+		value interface{}
+		want  float64
+	}{
+		{1.5, 1.5}, {float32(2), 2}, {3, 3}, {int8(-4), -4}, {int16(5), 5}, {int32(6), 6}, {int64(7), 7},
+		{uint(8), 8}, {uint8(9), 9}, {uint16(10), 10}, {uint32(11), 11}, {uint64(12), 12}, {amount(13), 13},
+		{stdjson.Number("14.5"), 14.5}, {"15", 0}, {nil, 0}, {true, 0},
+	}
+	for _, tt := range tests {
+		tree := []interface{}{map[string]interface{}{"value": tt.value}}
+		result, err := Tree.Sum(tree, "children", "value", "total")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := result[0].(map[string]interface{})["total"]; got != tt.want {
+			t.Fatalf("Sum(%T %v) = %v, want %v", tt.value, tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package underscore
+
+import (
+	"github.com/skyfox2000/nect-utils/tree"
+)
+
+// BuildTree 将带父 id 的列表构建为树，见 tree.Tree.BuildTree
+func (p *underscore) BuildTree(list []interface{}, idKey, parentKey, childrenKey string, rootIDs ...interface{}) ([]interface{}, error) {
+	return tree.Tree.BuildTree(list, idKey, parentKey, childrenKey, rootIDs...)
+}
+
+// CheckTree 检查列表中的重复 id、孤儿节点和循环引用
+func (p *underscore) CheckTree(list []interface{}, idKey, parentKey string, rootIDs ...interface{}) (*tree.Issues, error) {
+	return tree.Tree.Check(list, idKey, parentKey, rootIDs...)
+}
+
+// FlattenTree 将树按先序展开为列表
+func (p *underscore) FlattenTree(nodes []interface{}, childrenKey string) ([]interface{}, error) {
+	return tree.Tree.Flatten(nodes, childrenKey)
+}
+
+// WalkTree 先序遍历树，回调返回 false 时停止
+func (p *underscore) WalkTree(nodes []interface{}, childrenKey string, fn tree.WalkFunc) error {
+	return tree.Tree.Walk(nodes, childrenKey, fn)
+}
+
+// FindInTree 查找第一个匹配的节点，返回从根节点到该节点的路径
+func (p *underscore) FindInTree(nodes []interface{}, childrenKey string, predicate tree.PredicateFunc) ([]interface{}, error) {
+	return tree.Tree.Find(nodes, childrenKey, predicate)
+}
+
+// FilterTree 过滤树，保留匹配的节点及其祖先节点
+func (p *underscore) FilterTree(nodes []interface{}, childrenKey string, predicate tree.PredicateFunc) ([]interface{}, error) {
+	return tree.Tree.Filter(nodes, childrenKey, predicate)
+}
+
+// TreePath 返回从根节点到指定 id 节点的路径
+func (p *underscore) TreePath(nodes []interface{}, id interface{}, idKey, childrenKey string) ([]interface{}, error) {
+	return tree.Tree.PathTo(nodes, id, idKey, childrenKey)
+}
+
+// AggregateTree 自底向上聚合子树，结果保存在 resultKey 中
+func (p *underscore) AggregateTree(nodes []interface{}, childrenKey, resultKey string, fn tree.AggregateFunc) ([]interface{}, error) {
+	return tree.Tree.Aggregate(nodes, childrenKey, resultKey, fn)
+}
+
+// SumTree 汇总子树中 valueKey 的数值
+func (p *underscore) SumTree(nodes []interface{}, childrenKey, valueKey, resultKey string) ([]interface{}, error) {
+	return tree.Tree.Sum(nodes, childrenKey, valueKey, resultKey)
+}
+
+// CountTree 统计子树的节点数
+func (p *underscore) CountTree(nodes []interface{}, childrenKey, resultKey string) ([]interface{}, error) {
+	return tree.Tree.Count(nodes, childrenKey, resultKey)
+}
@@ -1,8 +1,10 @@
 package underscore
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
+	"math/big"
 	"reflect"
 	"sort"
 	"strings"
@@ -21,6 +23,10 @@ func (p *underscore) IsEmpty(val interface{}) bool {
 	if val == nil {
 		return true
 	}
+	if n, ok := val.(json.Number); ok {
+		r, ok := numberRat(n)
+		return !ok || r.Sign() == 0
+	}
 	switch reflect.TypeOf(val).Kind() {
 	case reflect.Array, reflect.Chan, reflect.Map, reflect.Slice, reflect.String:
 		return reflect.ValueOf(val).Len() == 0
@@ -49,6 +55,10 @@ func (p *underscore) IsNumber(value interface{}) bool {
 	if value == nil {
 		return false
 	}
+	// json.Number 为保留精度解析的数字
+	if _, ok := value.(json.Number); ok {
+		return true
+	}
 	switch reflect.TypeOf(value).Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
@@ -63,6 +73,9 @@ func (p *underscore) IsString(value interface{}) bool {
 	if value == nil {
 		return false
 	}
+	if _, ok := value.(json.Number); ok {
+		return false
+	}
 	switch reflect.TypeOf(value).Kind() {
 	case reflect.String:
 		return true
@@ -126,7 +139,7 @@ func (p *underscore) Len(value interface{}) int {
 	case reflect.Map:
 		return reflect.ValueOf(value).Len()
 	case reflect.String:
-		return len(value.(string))
+		return reflect.ValueOf(value).Len()
 	default:
 		return 0
 	}
@@ -192,6 +205,8 @@ func (p *underscore) SortBy(arr []interface{}, field, order string) []interface{
 			keys = append(keys, v)
 		} else if v, ok := reflect.ValueOf(k).Interface().(int); ok {
 			keys = append(keys, v)
+		} else if v, ok := k.(json.Number); ok {
+			keys = append(keys, v)
 		}
 	}
 
@@ -246,11 +261,31 @@ func (p *underscore) Sort(arr []interface{}, order string) interface{} {
 				return arr[i].(float64) < arr[j].(float64)
 			})
 		}
+	case json.Number:
+		// 按数值精确比较，大整数不经过 float64
+		less := func(i, j int) bool {
+			a, _ := numberRat(arr[i].(json.Number))
+			b, _ := numberRat(arr[j].(json.Number))
+			return a != nil && b != nil && a.Cmp(b) < 0
+		}
+		switch order {
+		case "asc":
+			sort.SliceStable(arr, less)
+		case "desc":
+			sort.SliceStable(arr, func(i, j int) bool {
+				return less(j, i)
+			})
+		}
 	}
 
 	return arr
 }
 
+// 将 json.Number 转换为精确的有理数
+func numberRat(n json.Number) (*big.Rat, bool) {
+	return new(big.Rat).SetString(n.String())
+}
+
 // Keys 获取map的所有键
 func (p *underscore) Keys(val interface{}, order ...bool) []string {
 	keys := make([]string, 0)